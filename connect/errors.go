@@ -0,0 +1,101 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Errors
+ *
+ * This component defines the errors that are returned by the error-returning variants (the "Try" functions) of
+ * the externally visible functionality. Callers can use errors.Is to check for these errors, and decide whether
+ * to retry, fall back, or surface the problem to their users.
+ * Note that, when the outbox is enabled, posting is fire-and-forget: postings that cannot be delivered for lack
+ * of connectivity are kept in the outbox, without returning an error (see the Layer 2 - Outbox component).
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/textproto"
+	"syscall"
+)
+
+var (
+	// No posting, or no file in the repository, could be found
+	ErrNotFound = errors.New("not found")
+
+	// A delta cannot be applied, as it is based on a different state than the current one
+	ErrStaleDelta = errors.New("stale delta")
+
+	// A chained update cannot be applied, as preceding updates are missing
+	ErrUpdateGap = errors.New("missing updates")
+
+	// The repository could not be reached, or refused the operation
+	ErrRepositoryUnavailable = errors.New("repository unavailable")
+
+	// The event bus could not be reached, or refused the operation
+	ErrBrokerUnavailable = errors.New("broker unavailable")
+
+	// The connector has been closed
+	ErrClosed = errors.New("connector closed")
+
+	// The config data is not valid, e.g. since it selects an unknown kind of event transport
+	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// A server of the repository is temporarily unavailable, e.g. when overloaded or in maintenance
+	errServerUnavailable = errors.New("server temporarily unavailable")
+)
+
+// Classify an error from a repository backend, as either ErrNotFound or ErrRepositoryUnavailable
+func repositoryError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+
+	case errors.Is(err, ErrNotFound) || errors.Is(err, ErrRepositoryUnavailable):
+		// Already classified
+		return err
+
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+
+	default:
+		return fmt.Errorf("%w: %w", ErrRepositoryUnavailable, err)
+	}
+}
+
+// Classify an error from an event transport as ErrBrokerUnavailable
+func brokerError(err error) error {
+	if err == nil || errors.Is(err, ErrBrokerUnavailable) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrBrokerUnavailable, err)
+}
+
+// Check whether an error is caused by a lack of connectivity to the repository or the event bus, in which case
+// the operation may succeed later. Other errors, such as the repository refusing the operation, are permanent.
+// As MQTT brokers do not refuse the publication of messages, all errors from the event bus count as a lack of
+// connectivity.
+func isConnectivityError(err error) bool {
+	var netError net.Error
+	var protocolError *textproto.Error
+
+	return errors.Is(err, ErrBrokerUnavailable) ||
+		errors.Is(err, errServerUnavailable) ||
+		errors.As(err, &netError) ||
+		(errors.As(err, &protocolError) && protocolError.Code >= 400 && protocolError.Code <= 499) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
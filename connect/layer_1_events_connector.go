@@ -4,51 +4,127 @@
  * Package:   Connect
  * Component: Layer 1 - Events Connector
  *
- * This comonent provides the connectivity to the MQTT-based event bus.
+ * This comonent provides the connectivity to the event bus.
+ * The actual publish/subscribe functionality is provided by an event transport (see EventTransport), which
+ * is selected by the "kind" key in the "events" section of the config file. By default, the MQTT-based
+ * event transport is used.
  * Most functionality is intended as internal functionality to be used by the other components of this package.
  * Nevertheless, some functionality is externally visible.
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 16.10.2026
  *
  */
 
 package connect
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
 
+/*
+ * Defining event transports
+ */
+
+type (
+	// An event transport provides the publish/subscribe functionality on which the events connector builds.
+	// Topics are always full topic paths. Topic filters may end with the "#" wildcard, matching all topics
+	// underneath the given topic root.
+	// The delivery parameters indicate the reliability needed for the messages concerned.
+	EventTransport interface {
+		Connect(ctx context.Context) error                                                                     // Connect to the underlying event bus
+		Publish(topic string, payload []byte, delivery TDelivery) error                                        // Publish a message on a topic
+		Subscribe(topicFilter string, delivery TDelivery, eventHandler TEventHandler) (int, error)             // Subscribe to the topics matching a topic filter, returning an ID for the event handler
+		Unsubscribe(topicFilter string, eventHandlerID int) error                                              // Remove an event handler from a topic filter
+		Delete(topic string, delivery TDelivery) error                                                         // Delete the retained message of a topic
+		RetainedTopics(ctx context.Context, topicFilter string, delivery TDelivery) (map[string][]byte, error) // Snapshot of the retained messages matching a topic filter
+		Sync(ctx context.Context) error                                                                        // Wait until all messages for the subscriptions made so far, including the retained ones, have been delivered
+		Disconnect(ctx context.Context) error                                                                  // Unsubscribe from all topic filters, and disconnect from the underlying event bus
+
+		// Set the handler to be called when the connection is lost, and when it has been restored.
+		// After restoring the connection, the event transport must have re-established all subscriptions.
+		SetConnectionStateHandler(connectionStateHandler TConnectionStateHandler)
+	}
+
+	// Delivery parameters for messages
+	TDelivery struct {
+		QoS    byte // The quality of service: 0 (at most once), 1 (at least once), or 2 (exactly once)
+		Retain bool // Whether the message is to be retained on the bus for later subscribers
+	}
+
+	// Handler for events received by an event transport
+	TEventHandler func(topic string, payload []byte)
+
+	// Handler for changes in the connection state of an event transport
+	TConnectionStateHandler func(connected bool)
+
+	// Creator of an event transport, based on the config data, returning an error when the config data is not valid
+	TEventTransportCreator func(configData *generics.TConfigData, reporter *generics.TReporter) (EventTransport, error)
+)
+
+const (
+	defaultEventTransportKind = mqttEventTransportKind // The kind of event transport used when none is configured
+)
+
+// The known event transport kinds
+var eventTransportCreators = map[string]TEventTransportCreator{}
+
+// The kinds of postings, for which the delivery can be configured, given the first element of their topic paths
+var postingKinds = map[string]string{
+	"artefacts":    "artefact",
+	"observations": "observation",
+	"coordination": "coordination",
+}
+
+// Error for postings while the connection to the event bus is lost
+var errEventBusDisconnected = fmt.Errorf("not connected to the event bus: %w", ErrBrokerUnavailable)
+
 /*
  * Defining the events connector
  */
 
 type (
 	tModellingBusEventsConnector struct {
-		user, // MQTT user
-		port, // MQTT port
-		broker, // MQTT broker
-		prefix, // MQTT topic prefix
-		agentID, // Agent ID to be used in postings on the MQTT bus
-		password, // MQTT password
+		prefix, // Topic prefix
+		agentID, // Agent ID to be used in postings on the event bus
 		environmentID string // Modelling environment ID
 
-		loadDelay int // Delay (in milliseconds) to allow messages to arrive from the MQTT bus
+		syncTimeout time.Duration // Maximum time to wait for synchronising with the event bus
+
+		defaultDelivery TDelivery            // Delivery of postings of other kinds
+		deliveries      map[string]TDelivery // Delivery per kind of posting
+
+		postingOnly bool // Whether the connector is only used for posting
 
-		connectionBeingOpenened bool // Whether the MQTT connection is still being opened.
+		connected atomic.Bool // Whether the event transport is currently connected
+		closed    atomic.Bool // Whether the events connector has been closed
+
+		subscriptionsMutex sync.Mutex             // Guards the subscriptions
+		subscriptions      map[*Subscription]bool // The active subscriptions of the agent
+
+		connectionBeingOpenened atomic.Bool // Whether the MQTT connection is still being opened.
 		// The opening phase is special, as we need to collect all existing messages on the bus. CHECK!!!
 
+		messagesMutex    sync.Mutex // Ensures messages are stored in both topic stores at once
 		currentMessages, // Currently known messages on the MQTT bus
-		openingMessages map[string][]byte // Messages known at the opening of the connection to the MQTT bus
+		openingMessages *tTopicStore // Messages known at the opening of the connection to the MQTT bus
 		// We need this to enable deletion of topics, as well as to be able to pro-actively
 		// pull information from the modelling bus
 
-		client mqtt.Client // The MQTT client
+		transport EventTransport // The event transport
+
+		// The handlers are called from the goroutines of the event transport, while they may be set at any time
+		connectionStateHandler atomic.Pointer[TConnectionStateHandler] // Handler of the agent for changes in the connection state
+		reconnectedHandler     atomic.Pointer[func()]                  // Handler of the modelling bus connector for restored connections
 
 		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
 	}
@@ -78,119 +154,288 @@ func (e *tModellingBusEventsConnector) mqttAgentTopicPath(agentID, topicPath str
 	return e.prefix + "/" + generics.ModellingBusVersion + "/" + e.environmentID + "/" + agentID + "/" + topicPath
 }
 
+// Get the ID of the agent a topic belongs to, being the first element of the topic after the environment topic root
+func (e *tModellingBusEventsConnector) agentIDOfTopic(topic string) string {
+	agentID, _, _ := strings.Cut(strings.TrimPrefix(topic, e.mqttEnvironmentTopicRoot()+"/"), "/")
+
+	return agentID
+}
+
+/*
+ * Determining the delivery of postings
+ */
+
+// Get the delivery for a given topic path (relative to the agent)
+func (e *tModellingBusEventsConnector) deliveryFor(topicPath string) TDelivery {
+	firstPathElement, _, _ := strings.Cut(topicPath, "/")
+	if delivery, defined := e.deliveries[postingKinds[firstPathElement]]; defined {
+		return delivery
+	}
+
+	return e.defaultDelivery
+}
+
+// Get the delivery for collecting all topics of a modelling environment, which needs to match the most
+// reliable delivery of all kinds of postings
+func (e *tModellingBusEventsConnector) collectionDelivery() TDelivery {
+	collectionDelivery := e.defaultDelivery
+	for _, delivery := range e.deliveries {
+		collectionDelivery.QoS = max(collectionDelivery.QoS, delivery.QoS)
+	}
+
+	return collectionDelivery
+}
+
 /*
- * Connecting to MQTT
+ * Matching topics
  */
 
-// Connection lost handler
-func (e *tModellingBusEventsConnector) connectionLostHandler(c mqtt.Client, err error) {
-	e.reporter.Panic("MQTT connection lost. %s", err)
+// Check whether a topic matches a topic filter, where the topic filter may contain the "+" (single level)
+// and "#" (multi level) wildcards
+func topicMatchesFilter(topicFilter, topic string) bool {
+	filterLevels := strings.Split(topicFilter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for level, filterLevel := range filterLevels {
+		switch {
+		case filterLevel == "#":
+			// The multi level wildcard matches all remaining levels
+			return true
+
+		case level >= len(topicLevels):
+			// The topic has fewer levels than the topic filter
+			return false
+
+		case filterLevel != "+" && filterLevel != topicLevels[level]:
+			// The levels differ, and the filter level is not a single level wildcard
+			return false
+		}
+	}
+
+	// All levels matched, so the topic should not have more levels than the topic filter
+	return len(filterLevels) == len(topicLevels)
 }
 
-// Wait for a while to allow messages to arrive from the MQTT bus
-func (e *tModellingBusEventsConnector) waitForMQTT() {
-	e.reporter.Progress(generics.ProgressLevelDetailed, "Sleeping for %d miliseconds to collect information from the MQTT bus.", e.loadDelay)
-	time.Sleep(time.Duration(e.loadDelay) * time.Second / 1000)
+/*
+ * Connecting to the event bus
+ */
+
+// Derive a context for synchronising with the event bus, bounded by the sync timeout
+func (e *tModellingBusEventsConnector) syncContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, e.syncTimeout)
 }
 
-// Collect all MQTT topics for a given modelling environment
-func (e *tModellingBusEventsConnector) collectTopicsForModellingEnvironment(environmentID string) {
-	token := e.client.Subscribe(e.mqttEnvironmentTopicListFor(environmentID), 0, func(client mqtt.Client, msg mqtt.Message) {
-		// Get topic and payload
-		topic := msg.Topic()
-		payload := msg.Payload()
+// Wait until all messages for the subscriptions made so far, including the retained ones, have arrived from the
+// event bus, unless the given context is done or the sync timeout has passed before
+func (e *tModellingBusEventsConnector) syncWithEventBus(ctx context.Context) error {
+	e.reporter.Progress(generics.ProgressLevelDetailed, "Synchronising with the event bus.")
+
+	syncCtx, cancel := e.syncContext(ctx)
+	defer cancel()
+
+	// Synchronising by means of the event transport
+	err := e.transport.Sync(syncCtx)
+	if err != nil {
+		return brokerError(fmt.Errorf("synchronising with the event bus: %w", err))
+	}
 
-		// Store the topic and payload
-		if len(payload) == 0 {
-			// If the payload is empty, the topic has been deleted
-			delete(e.openingMessages, topic)
-			delete(e.currentMessages, topic)
+	return nil
+}
+
+// Store a message received from the event bus
+func (e *tModellingBusEventsConnector) storeMessage(topic string, payload []byte) {
+	// Messages may arrive from different goroutines
+	e.messagesMutex.Lock()
+	defer e.messagesMutex.Unlock()
+
+	// Store the topic and payload
+	if len(payload) == 0 {
+		// If the payload is empty, the topic has been deleted
+		e.openingMessages.forget(topic)
+		e.currentMessages.forget(topic)
+	} else {
+		// Otherwise, store the message
+		if e.connectionBeingOpenened.Load() {
+			// During opening, we need to store both opening and current messages
+			e.openingMessages.store(topic, payload)
+			e.currentMessages.store(topic, payload)
 		} else {
-			// Otherwise, store the message
-			if e.connectionBeingOpenened {
-				// During opening, we need to store both opening and current messages
-				e.openingMessages[topic] = payload
-				e.currentMessages[topic] = payload
-			} else {
-				// After opening, we only need to store current messages
-				if _, defined := e.openingMessages[topic]; !defined {
-					// If not yet defined, define the openingMessage fot this topic with empty payload
-					e.openingMessages[topic] = []byte{}
-				}
-				e.currentMessages[topic] = payload
-			}
+			// After opening, we only need to store current messages
+			// If not yet defined, define the openingMessage fot this topic with empty payload
+			e.openingMessages.storeIfAbsent(topic, []byte{})
+			e.currentMessages.store(topic, payload)
 		}
-	})
+	}
+}
 
-	// Wait for the subscription to be in place
-	token.Wait()
+// Collect all topics for a given modelling environment
+func (e *tModellingBusEventsConnector) collectTopicsForModellingEnvironment(ctx context.Context, environmentID string) {
+	_, err := e.transport.Subscribe(e.mqttEnvironmentTopicListFor(environmentID), e.collectionDelivery(), e.storeMessage)
+
+	// Check whether the subscription is in place
+	if err != nil {
+		e.reporter.Error("Error subscribing to the topics of the modelling environment. %s", err)
+		return
+	}
 
-	// Wait for a while to allow messages to arrive from the MQTT bus
-	e.waitForMQTT()
+	// Wait until the retained messages have arrived from the event bus
+	if err := e.syncWithEventBus(ctx); err != nil {
+		e.reporter.Error("Error collecting the topics of the modelling environment. %s", err)
+	}
 
 	// List found topics
-	if len(e.openingMessages) == 0 {
+	if e.openingMessages.size() == 0 {
 		// No topics found
 		e.reporter.Progress(generics.ProgressLevelDetailed, "No topics found.")
 	} else {
 		// Topics found, so let's list them
 		e.reporter.Progress(generics.ProgressLevelDetailed, "Found topic(s):")
-		for topic := range e.openingMessages {
-			if strings.HasPrefix(topic, e.mqttEnvironmentTopicRoot()) {
-				e.reporter.Progress(generics.ProgressLevelDetailed, "- %s", topic)
-			}
+		for topic := range e.openingMessages.matching(e.mqttEnvironmentTopicListFor(e.environmentID)) {
+			e.reporter.Progress(generics.ProgressLevelDetailed, "- %s", topic)
 		}
 	}
 }
 
-// Connect to the MQTT broker
-func (e *tModellingBusEventsConnector) connectToMQTT(postingOnly bool) {
-	// Setting up MQTT connection options
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker("tcp://" + e.broker + ":" + e.port)
-	opts.SetUsername(e.user)
-	opts.SetPassword(e.password)
-	opts.SetConnectionLostHandler(e.connectionLostHandler)
+// Re-synchronise the current messages with the retained messages on the event bus, e.g. after a reconnection
+func (e *tModellingBusEventsConnector) resyncCurrentMessages() {
+	// Get the retained messages
+	syncCtx, cancel := e.syncContext(context.Background())
+	defer cancel()
 
-	// Connecting to the MQTT broker
-	connected := false
-	for !connected {
+	retainedTopics, err := e.transport.RetainedTopics(syncCtx, e.mqttEnvironmentTopicListFor(e.environmentID), e.collectionDelivery())
+	if err != nil {
+		e.reporter.Error("Error re-synchronising with the event bus. %s", err)
+		return
+	}
+
+	// Forget the topics that have been deleted in the meantime
+	for topic := range e.currentMessages.all() {
+		if _, retained := retainedTopics[topic]; !retained {
+			e.storeMessage(topic, []byte{})
+		}
+	}
+
+	// Store the retained messages
+	for topic, payload := range retainedTopics {
+		e.storeMessage(topic, payload)
+	}
+}
+
+// Handle changes in the connection state of the event transport
+func (e *tModellingBusEventsConnector) connectionStateChanged(connected bool) {
+	// Once closed, changes in the connection state are no longer relevant
+	if e.closed.Load() {
+		return
+	}
+
+	e.connected.Store(connected)
+
+	if connected {
+		e.reporter.Progress(generics.ProgressLevelBasic, "Connection to the event bus restored.")
+
+		// Unless we are postingOnly, we need to catch up with the messages posted in the meantime
+		if !e.postingOnly {
+			e.resyncCurrentMessages()
+		}
+	} else {
+		e.reporter.Progress(generics.ProgressLevelBasic, "Connection to the event bus lost.")
+	}
+
+	// Let the modelling bus connector catch up with postings made while the connection was lost
+	if reconnectedHandler := e.reconnectedHandler.Load(); connected && reconnectedHandler != nil && *reconnectedHandler != nil {
+		(*reconnectedHandler)()
+	}
+
+	// Notify the agent
+	if connectionStateHandler := e.connectionStateHandler.Load(); connectionStateHandler != nil && *connectionStateHandler != nil {
+		(*connectionStateHandler)(connected)
+	}
+}
+
+// Set the handler of the agent for changes in the connection state
+func (e *tModellingBusEventsConnector) setConnectionStateHandler(connectionStateHandler TConnectionStateHandler) {
+	e.connectionStateHandler.Store(&connectionStateHandler)
+}
+
+// Set the handler of the modelling bus connector for restored connections
+func (e *tModellingBusEventsConnector) setReconnectedHandler(reconnectedHandler func()) {
+	e.reconnectedHandler.Store(&reconnectedHandler)
+}
+
+// Connect to the event bus, retrying until this succeeds or the given context is done
+func (e *tModellingBusEventsConnector) connectToEventBus(ctx context.Context, postingOnly bool) error {
+	// Connecting to the event bus
+	for {
 		// Trying to connect
-		e.reporter.Progress(generics.ProgressLevelBasic, "Trying to connect to the MQTT broker.")
+		err := e.transport.Connect(ctx)
+		if err == nil {
+			break
+		}
 
-		// Creating the MQTT client
-		e.client = mqtt.NewClient(opts)
-		token := e.client.Connect()
-		token.Wait()
+		// Give up when the context is done
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 
-		// Checking for errors
-		err := token.Error()
-		if err != nil {
-			e.reporter.Error("Error connecting to the MQTT broker. %s", err)
+		// Otherwise, report the error and try again after a while
+		e.reporter.Error("Error connecting to the event bus. %s", err)
 
-			time.Sleep(5 * time.Second)
-		} else {
-			connected = true
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 
 	// Initialising message storage
-	e.openingMessages = map[string][]byte{}
-	e.currentMessages = map[string][]byte{}
-	if connected {
-		e.reporter.Progress(generics.ProgressLevelBasic, "Connected to the MQTT broker.")
-
-		if !postingOnly {
-			// Unless we will be postingOnly, continuously connect all used topics underneath the
-			// topic root, and their messages.
-			// We need this information to enable deletion of topics, as well as to be able to
-			// pro-actively pull information from the modelling bus
-			e.collectTopicsForModellingEnvironment(e.environmentID)
-		}
+	e.openingMessages.clear()
+	e.currentMessages.clear()
+	e.connected.Store(true)
+	e.reporter.Progress(generics.ProgressLevelBasic, "Connected to the event bus.")
+
+	if !postingOnly {
+		// Unless we will be postingOnly, continuously connect all used topics underneath the
+		// topic root, and their messages.
+		// We need this information to enable deletion of topics, as well as to be able to
+		// pro-actively pull information from the modelling bus
+		e.collectTopicsForModellingEnvironment(ctx, e.environmentID)
+	}
+
+	// Mark the opening phase as finished
+	e.connectionBeingOpenened.Store(false)
 
-		// Mark the opening phase as finished
-		e.connectionBeingOpenened = false
+	return nil
+}
+
+// Close the events connector, by unsubscribing from all topics and disconnecting from the event bus
+func (e *tModellingBusEventsConnector) close(ctx context.Context) error {
+	// Closing more than once has no further effect
+	if e.closed.Swap(true) {
+		return nil
+	}
+
+	// From now on, postings will fail
+	e.connected.Store(false)
+
+	// Disconnect from the event bus
+	err := e.transport.Disconnect(ctx)
+
+	// End all subscriptions, which also stops calling their event handlers
+	e.subscriptionsMutex.Lock()
+	for subscription := range e.subscriptions {
+		subscription.end(ErrClosed)
+	}
+	e.subscriptions = map[*Subscription]bool{}
+	e.subscriptionsMutex.Unlock()
+
+	// Check for errors in disconnecting
+	if err != nil {
+		return brokerError(fmt.Errorf("disconnecting from the event bus: %w", err))
 	}
+
+	e.reporter.Progress(generics.ProgressLevelBasic, "Disconnected from the event bus.")
+
+	return nil
 }
 
 /*
@@ -198,16 +443,20 @@ func (e *tModellingBusEventsConnector) connectToMQTT(postingOnly bool) {
  */
 
 // Post a message on a given topic path
-func (e *tModellingBusEventsConnector) postMessage(topicPath string, message []byte) {
+func (e *tModellingBusEventsConnector) postMessage(topicPath string, message []byte, delivery TDelivery) error {
+	// While the connection is lost, messages would get lost as well
+	if !e.connected.Load() {
+		return errEventBusDisconnected
+	}
+
 	// Posting the message
-	token := e.client.Publish(topicPath, 0, true, string(message))
-	token.Wait()
+	return brokerError(e.transport.Publish(topicPath, message, delivery))
 }
 
 // Post an event on a given topic path
-func (e *tModellingBusEventsConnector) postEvent(topicPath string, message []byte) {
+func (e *tModellingBusEventsConnector) postEvent(topicPath string, message []byte) error {
 	// Posting the event
-	e.postMessage(e.mqttAgentTopicPath(e.agentID, topicPath), message)
+	return e.postMessage(e.mqttAgentTopicPath(e.agentID, topicPath), message, e.deliveryFor(topicPath))
 }
 
 /*
@@ -215,45 +464,142 @@ func (e *tModellingBusEventsConnector) postEvent(topicPath string, message []byt
  */
 
 // Pro-actively get the (latest) message from the bus.
-func (e *tModellingBusEventsConnector) messageFromEvent(agentID, topicPath string) []byte {
+// An empty message is returned when there is no message on the given topic path.
+func (e *tModellingBusEventsConnector) messageFromEvent(ctx context.Context, agentID, topicPath string) ([]byte, error) {
 	// Getting the message
 	mqttTopicPath := e.mqttAgentTopicPath(agentID, topicPath)
 
 	// Getting the message
-	message := e.currentMessages[mqttTopicPath]
+	message := e.currentMessages.message(mqttTopicPath)
+
+	// The message may have been posted just now, while it is still underway to us.
+	// So, we synchronise with the event bus to make sure we have received all messages known to the event bus.
+	if len(message) == 0 && e.connected.Load() && !e.postingOnly {
+		if err := e.syncWithEventBus(ctx); err != nil {
+			return []byte{}, err
+		}
+
+		message = e.currentMessages.message(mqttTopicPath)
+	}
+
+	return message, nil
+}
+
+// Pro-actively get the (latest) messages on the topic paths directly underneath a given topic path, by the last
+// element of their topic path
+func (e *tModellingBusEventsConnector) messagesFromEventsBelow(ctx context.Context, agentID, topicPath string) (map[string][]byte, error) {
+	// Collect the retained messages from the event bus
+	syncCtx, cancel := e.syncContext(ctx)
+	defer cancel()
+
+	mqttTopicPath := e.mqttAgentTopicPath(agentID, topicPath)
+	retainedTopics, err := e.transport.RetainedTopics(syncCtx, mqttTopicPath+"/+", e.deliveryFor(topicPath))
+	if err != nil {
+		return map[string][]byte{}, brokerError(fmt.Errorf("collecting the topics underneath %s: %w", topicPath, err))
+	}
 
-	// When messageFromEvent is called too soon after opening the connection to the MQTT broker,
-	// we may not have received a message yet. So, we need to be "waitForMQTT" patient.
-	if len(message) == 0 {
-		e.waitForMQTT()
-		message = e.currentMessages[mqttTopicPath]
+	// Key the messages by the last element of their topic path
+	messages := map[string][]byte{}
+	for topic, message := range retainedTopics {
+		messages[strings.TrimPrefix(topic, mqttTopicPath+"/")] = message
 	}
 
-	return message
+	return messages, nil
 }
 
 /*
  *  Listening for events
  */
 
-// Listen for events on a given topic path for a given agent
-func (e *tModellingBusEventsConnector) listenForEvents(agentID, topicPath string, eventHandler func([]byte)) {
+// Listen for events on a given topic path for a given agent. Errors returned by the event handler are reported
+// by means of the subscription.
+// The event handler is called one event at a time, from a goroutine of the subscription. So, an event handler
+// may take its time, without holding up other subscriptions, and may even synchronise with the event bus itself,
+// e.g. by pro-actively getting messages from the bus.
+// Next to the payload, the event handler is given the ID of the agent that posted the event, which is derived
+// from the topic of the event. This matters when listening to all agents, by means of the "+" wildcard.
+func (e *tModellingBusEventsConnector) listenForEvents(agentID, topicPath string, eventHandler func(string, []byte) error) *Subscription {
 	// Getting the MQTT topic path
 	mqttTopicPath := e.mqttAgentTopicPath(agentID, topicPath)
 
-	// Setting up the subscription
-	token := e.client.Subscribe(mqttTopicPath, 0, func(client mqtt.Client, msg mqtt.Message) {
-		// Getting the payload
-		payload := msg.Payload()
+	// Creating the subscription
+	subscription := createSubscription(e.reporter)
+	if e.closed.Load() {
+		subscription.end(ErrClosed)
+		return subscription
+	}
+
+	// Setting up the subscription on the event bus.
+	// As the agent ID may be the "+" wildcard, the last payloads are kept per topic.
+	lastPayloadsMutex := sync.Mutex{}
+	lastPayloads := map[string][]byte{}
+	eventHandlerID, err := e.transport.Subscribe(mqttTopicPath, e.deliveryFor(topicPath), func(topic string, payload []byte) {
+		// Calling the event handler, if necessary.
+		// After a reconnection, the retained message may be delivered again, which should be ignored.
+		lastPayloadsMutex.Lock()
+		isNew := len(payload) > 0 && string(e.openingMessages.message(topic)) != string(payload) && string(lastPayloads[topic]) != string(payload)
+		if isNew {
+			lastPayloads[topic] = payload
+		}
+		lastPayloadsMutex.Unlock()
 
-		// Calling the event handler, if necessary
-		if len(payload) > 0 && string(e.openingMessages[mqttTopicPath]) != string(payload) {
-			eventHandler(payload)
+		// Queue the call of the event handler. Once ended, events that were already underway are ignored.
+		if isNew {
+			subscription.handlerQueue.queue(func() {
+				if !subscription.hasEnded() {
+					subscription.reportError(eventHandler(e.agentIDOfTopic(topic), payload))
+				}
+			})
 		}
 	})
 
-	// Waiting for the subscription to be in place
-	token.Wait()
+	// Checking whether the subscription is in place
+	if err != nil {
+		subscription.end(brokerError(fmt.Errorf("subscribing to %s: %w", mqttTopicPath, err)))
+		return subscription
+	}
+
+	// Keep track of the subscription, so it can be ended when closing
+	e.subscriptionsMutex.Lock()
+	e.subscriptions[subscription] = true
+	e.subscriptionsMutex.Unlock()
+
+	// Define how to end the subscription on the event bus
+	subscription.unsubscribe = func() error {
+		e.subscriptionsMutex.Lock()
+		delete(e.subscriptions, subscription)
+		e.subscriptionsMutex.Unlock()
+
+		return brokerError(e.transport.Unsubscribe(mqttTopicPath, eventHandlerID))
+	}
+
+	return subscription
+}
+
+// Listening for events, including the ones that were already posted when the connection was opened, and are
+// still current. These are normally ignored, but e.g. requests from other agents remain relevant until handled.
+func (e *tModellingBusEventsConnector) listenForPendingEvents(agentID, topicPath string, eventHandler func(string, []byte) error) *Subscription {
+	// Listen for new events
+	subscription := e.listenForEvents(agentID, topicPath, eventHandler)
+
+	// Collect the events from the opening, that are still current
+	pendingPayloads := map[string][]byte{}
+	for topic, payload := range e.openingMessages.matching(e.mqttAgentTopicPath(agentID, topicPath)) {
+		if len(payload) > 0 && string(e.currentMessages.message(topic)) == string(payload) {
+			pendingPayloads[topic] = payload
+		}
+	}
+
+	// Queue the calls of the event handler for these events
+	for topic, payload := range pendingPayloads {
+		subscription.handlerQueue.queue(func() {
+			if !subscription.hasEnded() {
+				subscription.reportError(eventHandler(e.agentIDOfTopic(topic), payload))
+			}
+		})
+	}
+
+	return subscription
 }
 
 /*
@@ -261,62 +607,144 @@ func (e *tModellingBusEventsConnector) listenForEvents(agentID, topicPath string
  */
 
 // Delete a given topic path
-func (e *tModellingBusEventsConnector) deletePath(topicPath string) {
-	// Deleting the path by posting an empty message
-	e.postMessage(topicPath, []byte{})
+func (e *tModellingBusEventsConnector) deletePath(topicPath string, delivery TDelivery) error {
+	// Deleting the path by means of the event transport
+	err := e.transport.Delete(topicPath, delivery)
+	if err != nil {
+		return brokerError(fmt.Errorf("deleting topic %s from the event bus: %w", topicPath, err))
+	}
+
+	return nil
 }
 
 // Delete a given topic path
-func (e *tModellingBusEventsConnector) deletePostingPath(topicPath string) {
-	// Deleting the path by posting an empty event
-	e.postEvent(topicPath, []byte{})
+func (e *tModellingBusEventsConnector) deletePostingPath(topicPath string) error {
+	// Deleting the path of the event
+	return e.deletePath(e.mqttAgentTopicPath(e.agentID, topicPath), e.deliveryFor(topicPath))
+}
+
+// Delete a given topic path of a given agent, e.g. when consuming a request posted by that agent
+func (e *tModellingBusEventsConnector) deleteAgentPostingPath(agentID, topicPath string) error {
+	// Deleting the path of the event
+	return e.deletePath(e.mqttAgentTopicPath(agentID, topicPath), e.deliveryFor(topicPath))
+}
+
+// Delete the topic paths directly underneath a given topic path
+func (e *tModellingBusEventsConnector) deletePostingPathsBelow(ctx context.Context, topicPath string) error {
+	// Collect the topic paths underneath the given one
+	messages, err := e.messagesFromEventsBelow(ctx, e.agentID, topicPath)
+	if err != nil {
+		return err
+	}
+
+	// Delete them
+	errs := []error{}
+	for pathElement := range messages {
+		errs = append(errs, e.deletePostingPath(topicPath+"/"+pathElement))
+	}
+
+	return errors.Join(errs...)
 }
 
 // Delete all topics for a given modelling environment
-func (e *tModellingBusEventsConnector) deleteEnvironment(environmentID string) {
+func (e *tModellingBusEventsConnector) deleteEnvironment(ctx context.Context, environmentID string) error {
 	// Collect all topics for the given modelling environment
-	e.collectTopicsForModellingEnvironment(environmentID)
+	syncCtx, cancel := e.syncContext(ctx)
+	defer cancel()
+
+	retainedTopics, err := e.transport.RetainedTopics(syncCtx, e.mqttEnvironmentTopicListFor(environmentID), e.collectionDelivery())
+	if err != nil {
+		return brokerError(fmt.Errorf("collecting the topics of the modelling environment: %w", err))
+	}
 
 	// Delete all topics for the given modelling environment
-	for topic := range e.openingMessages {
+	errs := []error{}
+	for topic := range retainedTopics {
 		// Check whether the topic belongs to the given modelling environment
 		if strings.HasPrefix(topic, e.mqttAgentTopicRootFor(environmentID, e.agentID)) {
 			// Delete the topic
-			e.deletePath(topic)
+			errs = append(errs, e.deletePath(topic, e.collectionDelivery()))
 		}
 	}
+
+	return errors.Join(errs...)
 }
 
 /*
  * Creating bus event connectors
  */
 
-// Create a modelling bus events connector
-func createModellingBusEventsConnector(environmentID, agentID string, configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) *tModellingBusEventsConnector {
+// Get the delivery from the "qos" and "retain" keys, with the given prefix, of the "mqtt" section in the config data
+func deliveryFromConfig(configData *generics.TConfigData, keyPrefix string, defaultDelivery TDelivery) (TDelivery, error) {
+	qosValue := configData.GetValue("mqtt", keyPrefix+"qos")
+	retainValue := configData.GetValue("mqtt", keyPrefix+"retain")
+
+	// Values that cannot be read fall back to each of the defaults
+	qos := qosValue.IntWithDefault(int(defaultDelivery.QoS))
+	if qosValue.String() != "" && (qos != qosValue.IntWithDefault(-1) || qos < 0 || qos > 2) {
+		return defaultDelivery, fmt.Errorf("%w: the %sqos should be 0, 1, or 2: %s", ErrInvalidConfig, keyPrefix, qosValue.String())
+	}
+	retain := retainValue.BoolWithDefault(defaultDelivery.Retain)
+	if retainValue.String() != "" && retain != retainValue.BoolWithDefault(!defaultDelivery.Retain) {
+		return defaultDelivery, fmt.Errorf("%w: the %sretain should be true or false: %s", ErrInvalidConfig, keyPrefix, retainValue.String())
+	}
+
+	return TDelivery{QoS: byte(qos), Retain: retain}, nil
+}
+
+// Create a modelling bus events connector, where the context bounds connecting to the event bus
+func createModellingBusEventsConnector(ctx context.Context, environmentID, agentID string, configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) (*tModellingBusEventsConnector, error) {
 	// Creating the events connector
 	e := tModellingBusEventsConnector{}
 
 	// Get data from the config file
-	e.port = configData.GetValue("mqtt", "port").String()
-	e.user = configData.GetValue("mqtt", "user").String()
-	e.broker = configData.GetValue("mqtt", "broker").String()
-	e.password = configData.GetValue("mqtt", "password").String()
 	e.prefix = configData.GetValue("mqtt", "prefix").String()
-	e.loadDelay = configData.GetValue("mqtt", "load_delay").IntWithDefault(1)
+	e.syncTimeout = time.Duration(configData.GetValue("mqtt", "sync_timeout").IntWithDefault(30)) * time.Second
+
+	// Get the delivery per kind of posting from the config file, e.g. "artefact_qos" and "artefact_retain"
+	var err error
+	e.defaultDelivery, err = deliveryFromConfig(configData, "", TDelivery{QoS: 0, Retain: true})
+	if err != nil {
+		return nil, err
+	}
+	e.deliveries = map[string]TDelivery{}
+	for _, postingKind := range postingKinds {
+		e.deliveries[postingKind], err = deliveryFromConfig(configData, postingKind+"_", e.defaultDelivery)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Select the event transport
+	kind := configData.GetValue("events", "kind").StringWithDefault(defaultEventTransportKind)
+	createEventTransport, known := eventTransportCreators[kind]
+	if !known {
+		return nil, fmt.Errorf("%w: unknown kind of event transport: %s", ErrInvalidConfig, kind)
+	}
+	transport, err := createEventTransport(configData, reporter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating the %s event transport: %w", ErrInvalidConfig, kind, err)
+	}
+	e.transport = transport
+	e.transport.SetConnectionStateHandler(e.connectionStateChanged)
 
 	// Initialising other data
-	e.connectionBeingOpenened = true
-	e.currentMessages = map[string][]byte{}
-	e.openingMessages = map[string][]byte{}
+	e.connectionBeingOpenened.Store(true)
+	e.currentMessages = createTopicStore()
+	e.subscriptions = map[*Subscription]bool{}
+	e.openingMessages = createTopicStore()
 	e.agentID = agentID
 	e.environmentID = environmentID
+	e.postingOnly = postingOnly
 	e.reporter = reporter
 
-	// Connect to MQTT
-	e.connectToMQTT(postingOnly)
+	// Connect to the event bus
+	if err := e.connectToEventBus(ctx, postingOnly); err != nil {
+		return &e, fmt.Errorf("connecting to the event bus: %w", err)
+	}
 
 	// Return the created events connector
-	return &e
+	return &e, nil
 }
 
 /*
@@ -330,3 +758,9 @@ const (
 	// In this case, the connector will not collect existing messages from the bus
 	PostingOnly = true
 )
+
+// Register an event transport kind, which can then be selected using the "kind" key in the "events"
+// section of the config file
+func RegisterEventTransport(kind string, createEventTransport TEventTransportCreator) {
+	eventTransportCreators[kind] = createEventTransport
+}
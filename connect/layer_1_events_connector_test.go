@@ -0,0 +1,87 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Events Connector (tests)
+ *
+ * These tests read the delivery per kind of posting from the config data.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDeliveriesFromConfig(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		mqttConfig string
+		deliveries map[string]TDelivery // Expected delivery per kind of posting
+		fails      bool
+	}{
+		{
+			name: "defaults",
+			deliveries: map[string]TDelivery{
+				"artefact":     {QoS: 0, Retain: true},
+				"observation":  {QoS: 0, Retain: true},
+				"coordination": {QoS: 0, Retain: true},
+			},
+		},
+		{
+			name:       "global",
+			mqttConfig: "qos = 1\nretain = false",
+			deliveries: map[string]TDelivery{
+				"artefact":     {QoS: 1, Retain: false},
+				"observation":  {QoS: 1, Retain: false},
+				"coordination": {QoS: 1, Retain: false},
+			},
+		},
+		{
+			name:       "per kind of posting",
+			mqttConfig: "qos = 1\nartefact_qos = 2\nobservation_retain = false",
+			deliveries: map[string]TDelivery{
+				"artefact":     {QoS: 2, Retain: true},
+				"observation":  {QoS: 1, Retain: false},
+				"coordination": {QoS: 1, Retain: true},
+			},
+		},
+		{name: "QoS too high", mqttConfig: "qos = 5", fails: true},
+		{name: "negative QoS", mqttConfig: "qos = -1", fails: true},
+		{name: "QoS not a number", mqttConfig: "qos = high", fails: true},
+		{name: "QoS per kind too high", mqttConfig: "coordination_qos = 3", fails: true},
+		{name: "retain not a boolean", mqttConfig: "artefact_retain = sometimes", fails: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configData := createTestConfigData(t, "agent", "[mqtt]\n"+test.mqttConfig)
+
+			e, err := createModellingBusEventsConnector(context.Background(), "test", "agent", configData, createTestReporter(t), PostingOnly)
+			if e != nil {
+				t.Cleanup(func() { e.close(context.Background()) })
+			}
+
+			if test.fails {
+				if !errors.Is(err, ErrInvalidConfig) {
+					t.Errorf("expected an invalid configuration, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("creating the events connector: %s", err)
+			}
+
+			for postingKind, delivery := range test.deliveries {
+				if e.deliveries[postingKind] != delivery {
+					t.Errorf("delivery of %s postings is %+v, expected %+v", postingKind, e.deliveries[postingKind], delivery)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Events Transport Memory
+ *
+ * This component provides an in-process event transport.
+ * All event transports (within one process) that use the same in-memory bus name share their topics, making it
+ * possible to run several agents, for instance in tests, without an MQTT broker.
+ * As with MQTT, messages are retained per topic, and publishing an empty payload deletes the topic.
+ * As all delivery takes place in-process, the quality of service is ignored.
+ * An in-memory bus exists as long as event transports are connected to it. Once the last one has disconnected,
+ * the bus is released, including its retained messages.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	memoryEventTransportKind = "memory"  // Kind of the in-memory event transport
+	defaultInMemoryName      = "default" // Name of the in-memory bus and repository used when none is configured
+)
+
+/*
+ * Defining the in-memory bus
+ */
+
+type (
+	tInMemorySubscription struct {
+		topicFilter  string        // The topic filter subscribed to
+		eventHandler TEventHandler // The event handler to be called
+	}
+
+	tInMemoryBus struct {
+		users int // The number of event transports connected to the bus, guarded by inMemoryBusesMutex
+
+		mutex sync.Mutex // Guards the retained messages and subscriptions
+
+		retainedMessages map[string][]byte        // The retained messages per topic
+		subscriptions    []*tInMemorySubscription // The subscriptions on the bus
+	}
+)
+
+var (
+	inMemoryBusesMutex sync.Mutex                   // Guards the in-memory buses
+	inMemoryBuses      = map[string]*tInMemoryBus{} // The in-memory buses, per name
+)
+
+// Start using the in-memory bus with the given name, creating it when needed
+func acquireInMemoryBus(name string) *tInMemoryBus {
+	inMemoryBusesMutex.Lock()
+	defer inMemoryBusesMutex.Unlock()
+
+	if _, defined := inMemoryBuses[name]; !defined {
+		inMemoryBuses[name] = &tInMemoryBus{
+			retainedMessages: map[string][]byte{},
+		}
+	}
+	inMemoryBuses[name].users++
+
+	return inMemoryBuses[name]
+}
+
+// Stop using the in-memory bus with the given name, releasing it when it is no longer used
+func releaseInMemoryBus(name string) {
+	inMemoryBusesMutex.Lock()
+	defer inMemoryBusesMutex.Unlock()
+
+	if bus, defined := inMemoryBuses[name]; defined {
+		bus.users--
+		if bus.users <= 0 {
+			delete(inMemoryBuses, name)
+		}
+	}
+}
+
+// Get the event handlers of the subscriptions matching a topic
+func (m *tInMemoryBus) eventHandlersFor(topic string) []TEventHandler {
+	eventHandlers := []TEventHandler{}
+	for _, subscription := range m.subscriptions {
+		if topicMatchesFilter(subscription.topicFilter, topic) {
+			eventHandlers = append(eventHandlers, subscription.eventHandler)
+		}
+	}
+
+	return eventHandlers
+}
+
+// Publish a message on the in-memory bus
+func (m *tInMemoryBus) publish(topic string, payload []byte, retain bool) {
+	// Make sure the payload cannot be changed by the publisher afterwards
+	payload = append([]byte{}, payload...)
+
+	// Retain the message, or delete the topic in case of an empty payload
+	m.mutex.Lock()
+	if len(payload) == 0 {
+		delete(m.retainedMessages, topic)
+	} else if retain {
+		m.retainedMessages[topic] = payload
+	}
+	eventHandlers := m.eventHandlersFor(topic)
+	m.mutex.Unlock()
+
+	// Deliver the message to the subscribers
+	for _, eventHandler := range eventHandlers {
+		eventHandler(topic, payload)
+	}
+}
+
+// Get a snapshot of the retained messages matching a topic filter
+func (m *tInMemoryBus) retainedTopics(topicFilter string) map[string][]byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	retainedTopics := map[string][]byte{}
+	for topic, payload := range m.retainedMessages {
+		if topicMatchesFilter(topicFilter, topic) {
+			retainedTopics[topic] = payload
+		}
+	}
+
+	return retainedTopics
+}
+
+// Subscribe to a topic filter on the in-memory bus
+func (m *tInMemoryBus) subscribe(topicFilter string, eventHandler TEventHandler) *tInMemorySubscription {
+	// Add the subscription
+	subscription := &tInMemorySubscription{
+		topicFilter:  topicFilter,
+		eventHandler: eventHandler,
+	}
+	m.mutex.Lock()
+	m.subscriptions = append(m.subscriptions, subscription)
+	m.mutex.Unlock()
+
+	// As with MQTT, the retained messages are delivered to new subscribers
+	for topic, payload := range m.retainedTopics(topicFilter) {
+		eventHandler(topic, payload)
+	}
+
+	return subscription
+}
+
+// Remove a subscription from the in-memory bus
+func (m *tInMemoryBus) unsubscribe(subscription *tInMemorySubscription) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for index, candidate := range m.subscriptions {
+		if candidate == subscription {
+			m.subscriptions = append(m.subscriptions[:index:index], m.subscriptions[index+1:]...)
+			return
+		}
+	}
+}
+
+/*
+ * Defining the in-memory event transport
+ */
+
+type (
+	tInMemoryEventTransport struct {
+		name     string        // The name of the in-memory bus used
+		bus      *tInMemoryBus // The in-memory bus used
+		released bool          // Whether the in-memory bus has been released, when disconnecting
+
+		subscriptionsMutex    sync.Mutex                     // Guards the in-memory bus used, and the subscriptions
+		subscriptions         map[int]*tInMemorySubscription // The subscriptions made by this event transport
+		subscriptionHandlerID int                            // Last used event handler ID
+	}
+)
+
+// Get the in-memory bus currently used
+func (t *tInMemoryEventTransport) currentBus() *tInMemoryBus {
+	t.subscriptionsMutex.Lock()
+	defer t.subscriptionsMutex.Unlock()
+
+	return t.bus
+}
+
+// Connect to the in-memory bus, using it again after disconnecting
+func (t *tInMemoryEventTransport) Connect(_ context.Context) error {
+	t.subscriptionsMutex.Lock()
+	defer t.subscriptionsMutex.Unlock()
+
+	if t.released {
+		t.bus = acquireInMemoryBus(t.name)
+		t.released = false
+	}
+
+	return nil
+}
+
+// Publish a message on a topic
+func (t *tInMemoryEventTransport) Publish(topic string, payload []byte, delivery TDelivery) error {
+	t.currentBus().publish(topic, payload, delivery.Retain)
+
+	return nil
+}
+
+// Subscribe to the topics matching a topic filter
+func (t *tInMemoryEventTransport) Subscribe(topicFilter string, _ TDelivery, eventHandler TEventHandler) (int, error) {
+	subscription := t.currentBus().subscribe(topicFilter, eventHandler)
+
+	t.subscriptionsMutex.Lock()
+	defer t.subscriptionsMutex.Unlock()
+
+	t.subscriptionHandlerID++
+	t.subscriptions[t.subscriptionHandlerID] = subscription
+
+	return t.subscriptionHandlerID, nil
+}
+
+// Remove an event handler from a topic filter
+func (t *tInMemoryEventTransport) Unsubscribe(_ string, eventHandlerID int) error {
+	t.subscriptionsMutex.Lock()
+	bus := t.bus
+	subscription, defined := t.subscriptions[eventHandlerID]
+	delete(t.subscriptions, eventHandlerID)
+	t.subscriptionsMutex.Unlock()
+
+	if defined {
+		bus.unsubscribe(subscription)
+	}
+
+	return nil
+}
+
+// Delete the retained message of a topic
+func (t *tInMemoryEventTransport) Delete(topic string, _ TDelivery) error {
+	t.currentBus().publish(topic, []byte{}, true)
+
+	return nil
+}
+
+// Snapshot of the retained messages matching a topic filter
+func (t *tInMemoryEventTransport) RetainedTopics(_ context.Context, topicFilter string, _ TDelivery) (map[string][]byte, error) {
+	return t.currentBus().retainedTopics(topicFilter), nil
+}
+
+// As messages are delivered synchronously on the in-memory bus, there is never anything underway
+func (t *tInMemoryEventTransport) Sync(_ context.Context) error {
+	return nil
+}
+
+// Remove all subscriptions made by this event transport from the in-memory bus, and stop using it
+func (t *tInMemoryEventTransport) Disconnect(_ context.Context) error {
+	t.subscriptionsMutex.Lock()
+	bus := t.bus
+	subscriptions := t.subscriptions
+	t.subscriptions = map[int]*tInMemorySubscription{}
+	released := t.released
+	t.released = true
+	t.subscriptionsMutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		bus.unsubscribe(subscription)
+	}
+
+	if !released {
+		releaseInMemoryBus(t.name)
+	}
+
+	return nil
+}
+
+// Set the handler to be called when the connection is lost or restored. As the in-memory bus is always
+// available, this handler will never be called.
+func (t *tInMemoryEventTransport) SetConnectionStateHandler(_ TConnectionStateHandler) {
+}
+
+/*
+ * Creating in-memory event transports
+ */
+
+// Create an in-memory event transport
+func createInMemoryEventTransport(configData *generics.TConfigData, reporter *generics.TReporter) (EventTransport, error) {
+	// Creating the event transport
+	t := tInMemoryEventTransport{}
+
+	// Get the in-memory bus to be used
+	t.name = configData.GetValue("memory", "name").StringWithDefault(defaultInMemoryName)
+	t.bus = acquireInMemoryBus(t.name)
+	t.subscriptions = map[int]*tInMemorySubscription{}
+
+	// Report on the configuration
+	reporter.Progress(generics.ProgressLevelDetailed, "Using in-memory bus: %s", t.name)
+
+	// Return the created event transport
+	return &t, nil
+}
+
+func init() {
+	RegisterEventTransport(memoryEventTransportKind, createInMemoryEventTransport)
+}
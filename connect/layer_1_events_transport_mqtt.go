@@ -0,0 +1,558 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Events Transport MQTT
+ *
+ * This component provides the MQTT-based event transport, using the functionality of "github.com/eclipse/paho.mqtt.golang".
+ * It is the default event transport of the events connector.
+ * The connection to the broker can be secured using TLS, by means of "ssl" or "wss" broker URLs, as well as a
+ * CA bundle and a client certificate (see generics.TConfigData.TLSConfig).
+ * To know when all retained messages have arrived, the transport publishes a (non-retained) marker on a topic
+ * of its own, and waits until it comes back. As an MQTT broker forwards the messages to a client in the order
+ * in which it has processed them, all messages from earlier subscriptions have been delivered by then. Since
+ * MQTT only guarantees this order for messages of the same quality of service, a marker is published for each
+ * quality of service used by the subscriptions.
+ * Subscribing to a topic filter that has been subscribed to before, makes the broker send its retained messages
+ * again. These are only passed on to the event handlers added by the new subscription, as the other event
+ * handlers have received them already.
+ * The topic root of the markers contains the agent ID as well as a random nonce, so the markers of different
+ * transports never coincide, even when these are created at the same moment.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	mqttEventTransportKind = "mqtt"                 // Kind of the MQTT-based event transport
+	defaultMQTTQuiesce     = 250 * time.Millisecond // Time for pending work to complete when disconnecting
+	syncNonceLength        = 8                      // Number of random bytes in the topic root of the sync markers
+)
+
+/*
+ * Defining the MQTT event transport
+ */
+
+type (
+	tMQTTEventTransport struct {
+		user, // MQTT user
+		port, // MQTT port
+		broker, // MQTT broker
+		password, // MQTT password
+		scheme, // Scheme of the broker URL, i.e. "tcp", "ssl", "ws", or "wss"
+		path, // Path of the broker URL, when using websockets
+		syncTopicRoot string // Topic root for the sync markers of this transport
+
+		tlsConfig *tls.Config // TLS configuration, if any
+
+		keepAlive, // Interval for checking whether the connection to the MQTT broker is alive
+		pingTimeout, // Time to wait for a response to a ping to the MQTT broker
+		maxReconnectInterval time.Duration // Maximum time between attempts to reconnect to the MQTT broker
+
+		hasConnected           atomic.Bool                             // Whether the MQTT client has connected before
+		connectionStateHandler atomic.Pointer[TConnectionStateHandler] // Handler to be called when the connection is lost or restored
+
+		subscriptionsMutex    sync.Mutex                       // Guards the subscriptions
+		subscriptions         map[string]map[int]TEventHandler // Event handlers per topic filter
+		subscriptionQoS       map[string]byte                  // Quality of service per topic filter
+		subscriptionHandlerID int                              // Last used event handler ID
+		retainedRecipients    map[string]map[int]bool          // Event handlers per re-subscribed topic filter, which are to receive its retained messages
+
+		syncMutex       sync.Mutex               // Guards the sync markers
+		syncSubscribed  bool                     // Whether the transport is subscribed to its sync markers
+		syncMarkers     map[string]chan struct{} // Sync markers that are underway, with the channels to close on arrival
+		syncMarkerCount int                      // Last used sync marker number
+
+		client       mqtt.Client                           // The MQTT client
+		createClient func(*mqtt.ClientOptions) mqtt.Client // Function to create the MQTT client
+
+		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
+	}
+)
+
+/*
+ * Connecting to MQTT
+ */
+
+// Connection lost handler
+func (t *tMQTTEventTransport) connectionLostHandler(c mqtt.Client, err error) {
+	t.reporter.Error("MQTT connection lost. %s", err)
+
+	// Notify about the lost connection
+	t.notifyConnectionState(false)
+}
+
+// Reconnecting handler
+func (t *tMQTTEventTransport) reconnectingHandler(c mqtt.Client, opts *mqtt.ClientOptions) {
+	t.reporter.Progress(generics.ProgressLevelBasic, "Trying to reconnect to the MQTT broker.")
+}
+
+// Connect handler, which is called for the initial connection as well as for each reconnection
+func (t *tMQTTEventTransport) connectHandler(c mqtt.Client) {
+	// The initial connection needs no further handling
+	if !t.hasConnected.Swap(true) {
+		return
+	}
+
+	t.reporter.Progress(generics.ProgressLevelBasic, "Reconnected to the MQTT broker.")
+
+	// As we use a clean session, we need to re-establish all subscriptions.
+	// All event handlers then need the retained messages, to catch up with the messages posted in the meantime.
+	t.subscriptionsMutex.Lock()
+	topicFiltersQoS := map[string]byte{}
+	for topicFilter := range t.subscriptions {
+		topicFiltersQoS[topicFilter] = t.subscriptionQoS[topicFilter]
+	}
+	t.retainedRecipients = map[string]map[int]bool{}
+	t.subscriptionsMutex.Unlock()
+
+	for topicFilter, qos := range topicFiltersQoS {
+		token := c.Subscribe(topicFilter, qos, t.routeMessages(topicFilter))
+		token.Wait()
+
+		if err := token.Error(); err != nil {
+			t.reporter.Error("Error re-subscribing to %s. %s", topicFilter, err)
+		}
+	}
+
+	// Notify about the restored connection
+	t.notifyConnectionState(true)
+}
+
+// Call the connection state handler, if any. The MQTT client calls its handlers from goroutines of its own.
+func (t *tMQTTEventTransport) notifyConnectionState(connected bool) {
+	if connectionStateHandler := t.connectionStateHandler.Load(); connectionStateHandler != nil && *connectionStateHandler != nil {
+		(*connectionStateHandler)(connected)
+	}
+}
+
+// The URL of the MQTT broker
+func (t *tMQTTEventTransport) brokerURL() string {
+	brokerURL := t.scheme + "://" + t.broker + ":" + t.port
+
+	// Websocket connections also need a path
+	if t.scheme == "ws" || t.scheme == "wss" {
+		brokerURL += "/" + strings.TrimPrefix(t.path, "/")
+	}
+
+	return brokerURL
+}
+
+// Wait for an MQTT token to complete, unless the given context is done before
+func waitForToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Connect to the MQTT broker
+func (t *tMQTTEventTransport) Connect(ctx context.Context) error {
+	// Setting up MQTT connection options
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(t.brokerURL())
+	if t.tlsConfig != nil {
+		opts.SetTLSConfig(t.tlsConfig)
+	}
+	opts.SetUsername(t.user)
+	opts.SetPassword(t.password)
+	opts.SetKeepAlive(t.keepAlive)
+	opts.SetPingTimeout(t.pingTimeout)
+	opts.SetAutoReconnect(true)
+	opts.SetMaxReconnectInterval(t.maxReconnectInterval)
+	opts.SetConnectionLostHandler(t.connectionLostHandler)
+	opts.SetReconnectingHandler(t.reconnectingHandler)
+	opts.SetOnConnectHandler(t.connectHandler)
+
+	// Trying to connect
+	t.reporter.Progress(generics.ProgressLevelBasic, "Trying to connect to the MQTT broker.")
+
+	// Creating the MQTT client
+	t.client = t.createClient(opts)
+	err := waitForToken(ctx, t.client.Connect())
+
+	// When giving up, make sure the client stops trying
+	if ctx.Err() != nil {
+		t.client.Disconnect(0)
+	}
+
+	// Return potential errors
+	return err
+}
+
+// Unsubscribe from all topic filters, and disconnect from the MQTT broker.
+// Pending work is given time to complete until the deadline of the given context, if any.
+func (t *tMQTTEventTransport) Disconnect(ctx context.Context) error {
+	// Forget about all subscriptions
+	t.subscriptionsMutex.Lock()
+	topicFilters := []string{}
+	for topicFilter := range t.subscriptions {
+		topicFilters = append(topicFilters, topicFilter)
+	}
+	t.subscriptions = map[string]map[int]TEventHandler{}
+	t.subscriptionQoS = map[string]byte{}
+	t.retainedRecipients = map[string]map[int]bool{}
+	t.subscriptionsMutex.Unlock()
+
+	// Unsubscribe from the topic filters
+	err := error(nil)
+	if len(topicFilters) > 0 && t.client.IsConnectionOpen() {
+		err = waitForToken(ctx, t.client.Unsubscribe(topicFilters...))
+	}
+
+	// Determine how long to wait for pending work to complete
+	quiesce := defaultMQTTQuiesce
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		quiesce = max(0, time.Until(deadline))
+	}
+
+	// Disconnect from the MQTT broker
+	t.client.Disconnect(uint(quiesce.Milliseconds()))
+
+	return err
+}
+
+// Set the handler to be called when the connection is lost or restored
+func (t *tMQTTEventTransport) SetConnectionStateHandler(connectionStateHandler TConnectionStateHandler) {
+	t.connectionStateHandler.Store(&connectionStateHandler)
+}
+
+/*
+ *  Publishing and deleting
+ */
+
+// Publish a message on a topic
+func (t *tMQTTEventTransport) Publish(topic string, payload []byte, delivery TDelivery) error {
+	// Publishing the message
+	token := t.client.Publish(topic, delivery.QoS, delivery.Retain, payload)
+	token.Wait()
+
+	// Return potential errors
+	return token.Error()
+}
+
+// Delete a topic by publishing an empty retained message
+func (t *tMQTTEventTransport) Delete(topic string, delivery TDelivery) error {
+	return t.Publish(topic, []byte{}, TDelivery{QoS: delivery.QoS, Retain: true})
+}
+
+/*
+ *  Subscribing
+ */
+
+// Route a received MQTT message to the event handlers of a topic filter. Retained messages, which the broker
+// sends as a result of re-subscribing to a topic filter, only go to the event handlers added by re-subscribing.
+func (t *tMQTTEventTransport) routeMessages(topicFilter string) mqtt.MessageHandler {
+	return func(client mqtt.Client, msg mqtt.Message) {
+		// Collect the event handlers for this topic filter
+		t.subscriptionsMutex.Lock()
+		retainedRecipients, resubscribed := t.retainedRecipients[topicFilter]
+		eventHandlers := []TEventHandler{}
+		for handlerID, eventHandler := range t.subscriptions[topicFilter] {
+			if !msg.Retained() || !resubscribed || retainedRecipients[handlerID] {
+				eventHandlers = append(eventHandlers, eventHandler)
+			}
+		}
+		t.subscriptionsMutex.Unlock()
+
+		// Call the event handlers
+		for _, eventHandler := range eventHandlers {
+			eventHandler(msg.Topic(), msg.Payload())
+		}
+	}
+}
+
+// Add an event handler for a topic filter, and (re-)subscribe to the topic filter.
+// Re-subscribing to a topic filter results in the retained messages to be sent again, which are then only
+// passed on to the added event handler (and other event handlers added by re-subscribing).
+// When several subscriptions to the same topic filter are made, the highest quality of service is used.
+func (t *tMQTTEventTransport) addSubscription(topicFilter string, qos byte, eventHandler TEventHandler) (int, error) {
+	// Register the event handler
+	t.subscriptionsMutex.Lock()
+	_, resubscribing := t.subscriptions[topicFilter]
+	if !resubscribing {
+		t.subscriptions[topicFilter] = map[int]TEventHandler{}
+		t.subscriptionQoS[topicFilter] = qos
+	}
+	qos = max(qos, t.subscriptionQoS[topicFilter])
+	t.subscriptionQoS[topicFilter] = qos
+	t.subscriptionHandlerID++
+	handlerID := t.subscriptionHandlerID
+	t.subscriptions[topicFilter][handlerID] = eventHandler
+	if resubscribing {
+		if _, defined := t.retainedRecipients[topicFilter]; !defined {
+			t.retainedRecipients[topicFilter] = map[int]bool{}
+		}
+		t.retainedRecipients[topicFilter][handlerID] = true
+	}
+	t.subscriptionsMutex.Unlock()
+
+	// Subscribe to the topic filter
+	token := t.client.Subscribe(topicFilter, qos, t.routeMessages(topicFilter))
+	token.Wait()
+
+	// Return the handler ID and potential errors
+	return handlerID, token.Error()
+}
+
+// Remove an event handler for a topic filter, and unsubscribe when no event handlers are left
+func (t *tMQTTEventTransport) removeSubscription(topicFilter string, handlerID int) error {
+	// Unregister the event handler
+	t.subscriptionsMutex.Lock()
+	delete(t.subscriptions[topicFilter], handlerID)
+	delete(t.retainedRecipients[topicFilter], handlerID)
+	if len(t.retainedRecipients[topicFilter]) == 0 {
+		delete(t.retainedRecipients, topicFilter)
+	}
+	lastHandler := len(t.subscriptions[topicFilter]) == 0
+	if lastHandler {
+		delete(t.subscriptions, topicFilter)
+		delete(t.subscriptionQoS, topicFilter)
+	}
+	t.subscriptionsMutex.Unlock()
+
+	// Unsubscribe from the topic filter, if no event handlers are left
+	if lastHandler {
+		token := t.client.Unsubscribe(topicFilter)
+		token.Wait()
+
+		return token.Error()
+	}
+
+	return nil
+}
+
+// Subscribe to the topics matching a topic filter
+func (t *tMQTTEventTransport) Subscribe(topicFilter string, delivery TDelivery, eventHandler TEventHandler) (int, error) {
+	return t.addSubscription(topicFilter, delivery.QoS, eventHandler)
+}
+
+// Remove an event handler from a topic filter
+func (t *tMQTTEventTransport) Unsubscribe(topicFilter string, eventHandlerID int) error {
+	return t.removeSubscription(topicFilter, eventHandlerID)
+}
+
+/*
+ *  Synchronising
+ */
+
+// Get a topic root for the sync markers of a transport of the given agent, made unique by means of a random nonce
+func syncTopicRootFor(prefix, agentID string) (string, error) {
+	nonce := make([]byte, syncNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return prefix + "/sync/" + generics.ModellingBusVersion + "/" + agentID + "/" + hex.EncodeToString(nonce), nil
+}
+
+// Handle the arrival of a sync marker
+func (t *tMQTTEventTransport) syncMarkerArrived(topic string, _ []byte) {
+	t.syncMutex.Lock()
+	defer t.syncMutex.Unlock()
+
+	// Let the waiting Sync know the marker has arrived
+	if markerArrived, waiting := t.syncMarkers[topic]; waiting {
+		close(markerArrived)
+		delete(t.syncMarkers, topic)
+	}
+}
+
+// Subscribe to the sync markers of this transport, if not done before
+func (t *tMQTTEventTransport) subscribeToSyncMarkers() error {
+	t.syncMutex.Lock()
+	defer t.syncMutex.Unlock()
+
+	if t.syncSubscribed {
+		return nil
+	}
+
+	// Subscribing by means of addSubscription ensures re-subscription after reconnecting.
+	// The markers are received with the quality of service they are published with.
+	_, err := t.addSubscription(t.syncTopicRoot+"/#", 2, t.syncMarkerArrived)
+	t.syncSubscribed = err == nil
+
+	return err
+}
+
+// Get the qualities of service used by the subscriptions, other than the one to the sync markers
+func (t *tMQTTEventTransport) subscriptionQoSLevels() map[byte]bool {
+	t.subscriptionsMutex.Lock()
+	defer t.subscriptionsMutex.Unlock()
+
+	qosLevels := map[byte]bool{}
+	for topicFilter, qos := range t.subscriptionQoS {
+		if topicFilter != t.syncTopicRoot+"/#" {
+			qosLevels[qos] = true
+		}
+	}
+
+	return qosLevels
+}
+
+// Wait until all messages for the subscriptions made so far, including the retained ones, have been delivered.
+// This is done by publishing a marker for each quality of service used by the subscriptions, and waiting until
+// these have come back.
+func (t *tMQTTEventTransport) Sync(ctx context.Context) error {
+	// Make sure we will receive the markers
+	if err := t.subscribeToSyncMarkers(); err != nil {
+		return err
+	}
+
+	// Create fresh markers
+	t.syncMutex.Lock()
+	t.syncMarkerCount++
+	markerTopicRoot := t.syncTopicRoot + "/" + strconv.Itoa(t.syncMarkerCount)
+	markersArrived := map[string]chan struct{}{}
+	markersQoS := map[string]byte{}
+	for qos := range t.subscriptionQoSLevels() {
+		markerTopic := markerTopicRoot + "/" + strconv.Itoa(int(qos))
+		markersArrived[markerTopic] = make(chan struct{})
+		markersQoS[markerTopic] = qos
+		t.syncMarkers[markerTopic] = markersArrived[markerTopic]
+	}
+	t.syncMutex.Unlock()
+
+	// Forget the markers when giving up
+	defer func() {
+		t.syncMutex.Lock()
+		for markerTopic := range markersArrived {
+			delete(t.syncMarkers, markerTopic)
+		}
+		t.syncMutex.Unlock()
+	}()
+
+	// Publish the markers, without retaining them
+	for markerTopic, qos := range markersQoS {
+		if err := waitForToken(ctx, t.client.Publish(markerTopic, qos, false, []byte("sync"))); err != nil {
+			return err
+		}
+	}
+
+	// Wait for the markers to come back
+	for _, markerArrived := range markersArrived {
+		select {
+		case <-markerArrived:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Snapshot of the retained messages matching a topic filter
+func (t *tMQTTEventTransport) RetainedTopics(ctx context.Context, topicFilter string, delivery TDelivery) (map[string][]byte, error) {
+	retainedTopicsMutex := sync.Mutex{}
+	retainedTopics := map[string][]byte{}
+
+	// Temporarily subscribe to the topic filter to collect the retained messages
+	handlerID, err := t.addSubscription(topicFilter, delivery.QoS, func(topic string, payload []byte) {
+		retainedTopicsMutex.Lock()
+		defer retainedTopicsMutex.Unlock()
+
+		if len(payload) == 0 {
+			delete(retainedTopics, topic)
+		} else {
+			retainedTopics[topic] = payload
+		}
+	})
+	if err != nil {
+		return retainedTopics, err
+	}
+
+	// Wait until the retained messages have arrived from the MQTT bus
+	syncErr := t.Sync(ctx)
+
+	// Remove the temporary subscription again
+	err = errors.Join(syncErr, t.removeSubscription(topicFilter, handlerID))
+
+	// Return a snapshot of the retained messages
+	retainedTopicsMutex.Lock()
+	defer retainedTopicsMutex.Unlock()
+
+	snapshot := map[string][]byte{}
+	for topic, payload := range retainedTopics {
+		snapshot[topic] = payload
+	}
+
+	return snapshot, err
+}
+
+/*
+ * Creating MQTT event transports
+ */
+
+// Create an MQTT event transport
+func createMQTTEventTransport(configData *generics.TConfigData, reporter *generics.TReporter) (EventTransport, error) {
+	// Creating the event transport
+	t := tMQTTEventTransport{}
+
+	// Get data from the config file
+	t.port = configData.GetValue("mqtt", "port").String()
+	t.user = configData.GetValue("mqtt", "user").String()
+	t.broker = configData.GetValue("mqtt", "broker").String()
+	t.password = configData.GetValue("mqtt", "password").String()
+	t.path = configData.GetValue("mqtt", "path").StringWithDefault("mqtt")
+	t.keepAlive = time.Duration(configData.GetValue("mqtt", "keep_alive").IntWithDefault(30)) * time.Second
+	t.pingTimeout = time.Duration(configData.GetValue("mqtt", "ping_timeout").IntWithDefault(10)) * time.Second
+	t.maxReconnectInterval = time.Duration(configData.GetValue("mqtt", "max_reconnect_interval").IntWithDefault(60)) * time.Second
+
+	// Get the TLS configuration from the config file
+	tlsConfig, err := configData.TLSConfig("mqtt")
+	if err != nil {
+		return nil, fmt.Errorf("configuring TLS for the MQTT broker: %w", err)
+	}
+	t.tlsConfig = tlsConfig
+
+	// When TLS is configured, the default is to use "ssl" rather than "tcp"
+	if t.tlsConfig != nil {
+		t.scheme = configData.GetValue("mqtt", "scheme").StringWithDefault("ssl")
+	} else {
+		t.scheme = configData.GetValue("mqtt", "scheme").StringWithDefault("tcp")
+	}
+
+	// Initialising other data
+	t.subscriptions = map[string]map[int]TEventHandler{}
+	t.subscriptionQoS = map[string]byte{}
+	t.retainedRecipients = map[string]map[int]bool{}
+	t.syncMarkers = map[string]chan struct{}{}
+	t.createClient = mqtt.NewClient
+	t.reporter = reporter
+
+	// The sync markers are kept apart from the modelling environments, and unique for this transport
+	t.syncTopicRoot, err = syncTopicRootFor(configData.GetValue("mqtt", "prefix").String(), configData.GetValue("", "agent").String())
+	if err != nil {
+		return nil, fmt.Errorf("creating the topic root for sync markers: %w", err)
+	}
+
+	// Return the created event transport
+	return &t, nil
+}
+
+func init() {
+	RegisterEventTransport(mqttEventTransportKind, createMQTTEventTransport)
+}
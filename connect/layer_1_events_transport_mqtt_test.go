@@ -0,0 +1,504 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Events Transport MQTT (tests)
+ *
+ * These tests run the MQTT event transport against a fake MQTT broker, which can drop and restore the connections
+ * of its clients. The fake broker retains messages per topic, and delivers them synchronously.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining the fake MQTT broker
+ */
+
+type (
+	// A token of the fake MQTT client, which has always completed
+	tTestMQTTToken struct {
+		err error // The error of the operation, if any
+	}
+
+	// A message delivered by the fake MQTT broker
+	tTestMQTTMessage struct {
+		topic    string // The topic of the message
+		payload  []byte // The payload of the message
+		qos      byte   // The quality of service of the message
+		retained bool   // Whether the message is sent as a result of subscribing
+	}
+
+	// A fake MQTT broker
+	tTestMQTTBroker struct {
+		mutex sync.Mutex // Guards the retained messages and clients
+
+		retainedMessages map[string][]byte  // The retained messages per topic
+		clients          []*tTestMQTTClient // The clients created for the broker
+	}
+
+	// A client of the fake MQTT broker
+	tTestMQTTClient struct {
+		broker  *tTestMQTTBroker    // The broker of the client
+		options *mqtt.ClientOptions // The options the client was created with
+
+		mutex         sync.Mutex                     // Guards the connection state and subscriptions
+		connected     bool                           // Whether the client is connected
+		subscriptions map[string]mqtt.MessageHandler // The handlers per subscribed topic filter
+		published     []tTestMQTTMessage             // The messages published by the client
+	}
+)
+
+func (t tTestMQTTToken) Wait() bool                     { return true }
+func (t tTestMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t tTestMQTTToken) Error() error                   { return t.err }
+
+func (t tTestMQTTToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+
+	return done
+}
+
+func (m tTestMQTTMessage) Duplicate() bool   { return false }
+func (m tTestMQTTMessage) Qos() byte         { return m.qos }
+func (m tTestMQTTMessage) Retained() bool    { return m.retained }
+func (m tTestMQTTMessage) Topic() string     { return m.topic }
+func (m tTestMQTTMessage) MessageID() uint16 { return 0 }
+func (m tTestMQTTMessage) Payload() []byte   { return m.payload }
+func (m tTestMQTTMessage) Ack()              {}
+
+// Create a fake MQTT broker
+func createTestMQTTBroker() *tTestMQTTBroker {
+	return &tTestMQTTBroker{
+		retainedMessages: map[string][]byte{},
+	}
+}
+
+// Create a client of the fake MQTT broker, as replacement of mqtt.NewClient
+func (b *tTestMQTTBroker) createClient(options *mqtt.ClientOptions) mqtt.Client {
+	c := &tTestMQTTClient{
+		broker:        b,
+		options:       options,
+		subscriptions: map[string]mqtt.MessageHandler{},
+	}
+
+	b.mutex.Lock()
+	b.clients = append(b.clients, c)
+	b.mutex.Unlock()
+
+	return c
+}
+
+// Get the client that was created last
+func (b *tTestMQTTBroker) lastClient() *tTestMQTTClient {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.clients[len(b.clients)-1]
+}
+
+// Publish a message to the connected clients with matching subscriptions
+func (b *tTestMQTTBroker) publish(message tTestMQTTMessage, retain bool) {
+	b.mutex.Lock()
+	if len(message.payload) == 0 {
+		delete(b.retainedMessages, message.topic)
+	} else if retain {
+		b.retainedMessages[message.topic] = message.payload
+	}
+	clients := slices.Clone(b.clients)
+	b.mutex.Unlock()
+
+	for _, client := range clients {
+		client.deliver(message)
+	}
+}
+
+// Get the retained messages matching a topic filter
+func (b *tTestMQTTBroker) retainedMessagesFor(topicFilter string) []tTestMQTTMessage {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	messages := []tTestMQTTMessage{}
+	for topic, payload := range b.retainedMessages {
+		if topicMatchesFilter(topicFilter, topic) {
+			messages = append(messages, tTestMQTTMessage{topic: topic, payload: payload, retained: true})
+		}
+	}
+
+	return messages
+}
+
+// Deliver a message to the handlers of the matching subscriptions. As with the MQTT client, a message is routed
+// to all subscriptions matching its topic.
+func (c *tTestMQTTClient) deliver(message tTestMQTTMessage) {
+	c.mutex.Lock()
+	handlers := []mqtt.MessageHandler{}
+	for topicFilter, handler := range c.subscriptions {
+		if c.connected && topicMatchesFilter(topicFilter, message.topic) {
+			handlers = append(handlers, handler)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(c, message)
+	}
+}
+
+func (c *tTestMQTTClient) IsConnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.connected
+}
+
+func (c *tTestMQTTClient) IsConnectionOpen() bool {
+	return c.IsConnected()
+}
+
+func (c *tTestMQTTClient) Connect() mqtt.Token {
+	c.reconnect()
+
+	return tTestMQTTToken{}
+}
+
+func (c *tTestMQTTClient) Disconnect(_ uint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.connected = false
+	c.subscriptions = map[string]mqtt.MessageHandler{}
+}
+
+func (c *tTestMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	message := tTestMQTTMessage{topic: topic, payload: payload.([]byte), qos: qos}
+
+	c.mutex.Lock()
+	connected := c.connected
+	if connected {
+		c.published = append(c.published, message)
+	}
+	c.mutex.Unlock()
+
+	if !connected {
+		return tTestMQTTToken{err: errors.New("not connected")}
+	}
+
+	c.broker.publish(message, retained)
+
+	return tTestMQTTToken{}
+}
+
+func (c *tTestMQTTClient) Subscribe(topicFilter string, _ byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.mutex.Lock()
+	connected := c.connected
+	if connected {
+		c.subscriptions[topicFilter] = callback
+	}
+	c.mutex.Unlock()
+
+	if !connected {
+		return tTestMQTTToken{err: errors.New("not connected")}
+	}
+
+	// Send the retained messages matching the topic filter
+	for _, message := range c.broker.retainedMessagesFor(topicFilter) {
+		c.deliver(message)
+	}
+
+	return tTestMQTTToken{}
+}
+
+func (c *tTestMQTTClient) SubscribeMultiple(topicFilters map[string]byte, callback mqtt.MessageHandler) mqtt.Token {
+	for topicFilter, qos := range topicFilters {
+		if token := c.Subscribe(topicFilter, qos, callback); token.Error() != nil {
+			return token
+		}
+	}
+
+	return tTestMQTTToken{}
+}
+
+func (c *tTestMQTTClient) Unsubscribe(topicFilters ...string) mqtt.Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, topicFilter := range topicFilters {
+		delete(c.subscriptions, topicFilter)
+	}
+
+	return tTestMQTTToken{}
+}
+
+func (c *tTestMQTTClient) AddRoute(_ string, _ mqtt.MessageHandler) {
+}
+
+func (c *tTestMQTTClient) OptionsReader() mqtt.ClientOptionsReader {
+	return mqtt.NewOptionsReader(c.options)
+}
+
+// Drop the connection of the client, which, as with a clean session, also ends its subscriptions
+func (c *tTestMQTTClient) loseConnection() {
+	c.Disconnect(0)
+
+	c.options.OnConnectionLost(c, errors.New("connection dropped by the fake broker"))
+}
+
+// Restore the connection of the client
+func (c *tTestMQTTClient) reconnect() {
+	c.mutex.Lock()
+	c.connected = true
+	c.mutex.Unlock()
+
+	c.options.OnConnect(c)
+}
+
+// Get the messages published by the client on topics matching the given topic filter
+func (c *tTestMQTTClient) publishedOn(topicFilter string) []tTestMQTTMessage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	messages := []tTestMQTTMessage{}
+	for _, message := range c.published {
+		if topicMatchesFilter(topicFilter, message.topic) {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+/*
+ * Creating transports and connectors using the fake MQTT broker
+ */
+
+// Create an MQTT event transport for tests, using the fake MQTT broker
+func createTestMQTTTransport(t *testing.T, broker *tTestMQTTBroker, configData *generics.TConfigData) *tMQTTEventTransport {
+	transport, err := createMQTTEventTransport(configData, createTestReporter(t))
+	if err != nil {
+		t.Fatalf("creating the transport: %s", err)
+	}
+	mqttTransport := transport.(*tMQTTEventTransport)
+	mqttTransport.createClient = broker.createClient
+
+	return mqttTransport
+}
+
+// Create a connector for an agent in tests, using the MQTT event transport with the fake MQTT broker, and the
+// in-memory repository named after the test
+func createTestMQTTConnector(t *testing.T, broker *tTestMQTTBroker, agentID string) (TModellingBusConnector, *tTestMQTTClient) {
+	// Make the fake MQTT broker available as a kind of event transport of its own
+	kind := "test-mqtt-" + t.Name()
+	RegisterEventTransport(kind, func(configData *generics.TConfigData, _ *generics.TReporter) (EventTransport, error) {
+		return createTestMQTTTransport(t, broker, configData), nil
+	})
+
+	configData, err := generics.TryLoadConfigFromData([]byte(fmt.Sprintf("environment = test\nagent = %s\nwork_folder = %s\n\n"+
+		"[events]\nkind = %s\n\n[repository]\nkind = memory\n\n[mqtt]\nprefix = test\n\n[memory]\nname = %s\n", agentID, t.TempDir(), kind, t.Name())))
+	if err != nil {
+		t.Fatalf("loading the config data: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	connector, err := CreateModellingBusConnectorContext(ctx, configData, createTestReporter(t), false)
+	if err != nil {
+		t.Fatalf("creating the connector: %s", err)
+	}
+	t.Cleanup(func() {
+		connector.Close(context.Background())
+	})
+
+	return connector, broker.lastClient()
+}
+
+/*
+ * Testing reconnections
+ */
+
+func TestMQTTTransportRestoresSubscriptionsAfterReconnecting(t *testing.T) {
+	broker := createTestMQTTBroker()
+	transport := createTestMQTTTransport(t, broker, createTestConfigData(t, "agent"))
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("connecting: %s", err)
+	}
+	client := broker.lastClient()
+
+	states := []bool{}
+	transport.SetConnectionStateHandler(func(connected bool) { states = append(states, connected) })
+
+	received := []string{}
+	if _, err := transport.Subscribe("test/#", TDelivery{QoS: 1}, func(_ string, payload []byte) {
+		received = append(received, string(payload))
+	}); err != nil {
+		t.Fatalf("subscribing: %s", err)
+	}
+
+	// While the connection is lost, messages cannot be received
+	client.loseConnection()
+	broker.publish(tTestMQTTMessage{topic: "test/a", payload: []byte("missed")}, false)
+
+	// After reconnecting, the subscription is in place again
+	client.reconnect()
+	if err := transport.Publish("test/a", []byte("received"), TDelivery{QoS: 1}); err != nil {
+		t.Fatalf("publishing: %s", err)
+	}
+
+	if !slices.Equal(states, []bool{false, true}) {
+		t.Errorf("expected the connection to be lost and restored, got %v", states)
+	}
+	if !slices.Equal(received, []string{"received"}) {
+		t.Errorf("expected only the message published after reconnecting, got %v", received)
+	}
+}
+
+func TestConnectorCatchesUpAfterReconnecting(t *testing.T) {
+	broker := createTestMQTTBroker()
+	poster, _ := createTestMQTTConnector(t, broker, "poster")
+	listener, listenerClient := createTestMQTTConnector(t, broker, "listener")
+
+	states := make(chan bool, 2)
+	listener.SetConnectionStateHandler(func(connected bool) { states <- connected })
+
+	received := make(chan string, 2)
+	subscription := listener.ListenForStreamedObservationPostings("poster", "clicks", func(json []byte, _ string) { received <- string(json) })
+	defer subscription.Unsubscribe()
+
+	// While the connection is lost, the postings of others are missed, and our own postings are kept in the outbox
+	listenerClient.loseConnection()
+	if connected := <-states; connected {
+		t.Fatal("expected the connection to be lost")
+	}
+
+	if err := poster.TryPostStreamedObservation("clicks", []byte(`1`)); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+	if err := listener.TryPostStreamedObservation("status", []byte(`"back"`)); err != nil {
+		t.Fatalf("posting while disconnected: %s", err)
+	}
+
+	// After reconnecting, the listener receives the missed posting, and the outbox is delivered
+	listenerClient.reconnect()
+	if connected := <-states; !connected {
+		t.Fatal("expected the connection to be restored")
+	}
+
+	select {
+	case click := <-received:
+		if click != `1` {
+			t.Errorf("expected the missed posting, got %s", click)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("the missed posting did not arrive in time")
+	}
+
+	if status, _, err := poster.TryGetStreamedObservation("listener", "status"); err != nil || string(status) != `"back"` {
+		t.Errorf("expected the posting from the outbox, got %s (%v)", status, err)
+	}
+}
+
+func TestConnectionStateHandlerCanBeSetWhileReconnecting(t *testing.T) {
+	broker := createTestMQTTBroker()
+	listener, listenerClient := createTestMQTTConnector(t, broker, "listener")
+
+	// The MQTT client calls the handlers from goroutines of its own, while the agent may set them at any time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for range 10 {
+			listenerClient.loseConnection()
+			listenerClient.reconnect()
+		}
+	}()
+
+	for range 10 {
+		listener.SetConnectionStateHandler(func(bool) {})
+	}
+	<-done
+}
+
+/*
+ * Testing synchronisation
+ */
+
+func TestMQTTSyncPublishesAMarkerPerQoS(t *testing.T) {
+	broker := createTestMQTTBroker()
+	transport := createTestMQTTTransport(t, broker, createTestConfigData(t, "agent"))
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("connecting: %s", err)
+	}
+
+	for topicFilter, qos := range map[string]byte{"test/a": 0, "test/b": 1} {
+		if _, err := transport.Subscribe(topicFilter, TDelivery{QoS: qos}, func(string, []byte) {}); err != nil {
+			t.Fatalf("subscribing: %s", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := transport.Sync(ctx); err != nil {
+		t.Fatalf("synchronising: %s", err)
+	}
+
+	// As MQTT only orders messages of the same quality of service, each needs a marker of its own
+	markersQoS := []byte{}
+	for _, marker := range broker.lastClient().publishedOn(transport.syncTopicRoot + "/#") {
+		markersQoS = append(markersQoS, marker.qos)
+	}
+	slices.Sort(markersQoS)
+	if !slices.Equal(markersQoS, []byte{0, 1}) {
+		t.Errorf("expected markers with a quality of service of 0 and 1, got %v", markersQoS)
+	}
+}
+
+func TestMQTTRetainedTopicsDoNotReachOtherEventHandlers(t *testing.T) {
+	broker := createTestMQTTBroker()
+	broker.publish(tTestMQTTMessage{topic: "test/a", payload: []byte("retained")}, true)
+
+	transport := createTestMQTTTransport(t, broker, createTestConfigData(t, "agent"))
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("connecting: %s", err)
+	}
+
+	received := []string{}
+	if _, err := transport.Subscribe("test/#", TDelivery{}, func(_ string, payload []byte) {
+		received = append(received, string(payload))
+	}); err != nil {
+		t.Fatalf("subscribing: %s", err)
+	}
+
+	// Taking a snapshot of the retained messages re-subscribes to the same topic filter
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	retainedTopics, err := transport.RetainedTopics(ctx, "test/#", TDelivery{})
+	if err != nil {
+		t.Fatalf("taking the snapshot: %s", err)
+	}
+
+	if string(retainedTopics["test/a"]) != "retained" {
+		t.Errorf("expected the retained message in the snapshot, got %v", retainedTopics)
+	}
+	if !slices.Equal(received, []string{"retained"}) {
+		t.Errorf("expected the retained message to be received only once, got %v", received)
+	}
+}
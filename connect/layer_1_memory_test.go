@@ -0,0 +1,242 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Memory (tests)
+ *
+ * These tests run agents against the in-memory event transport and repository backend. The helpers defined
+ * here are used by the other tests of this package as well.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	testTimeout = 5 * time.Second // Time to wait for postings to arrive in tests
+)
+
+// Create a reporter for tests, which stops logging once the test has ended, as goroutines of the connectors may
+// still report while closing
+func createTestReporter(t *testing.T) *generics.TReporter {
+	mutex := sync.Mutex{}
+	ended := false
+	t.Cleanup(func() {
+		mutex.Lock()
+		ended = true
+		mutex.Unlock()
+	})
+
+	log := func(message string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if !ended {
+			t.Log(message)
+		}
+	}
+
+	return generics.CreateReporter(generics.ProgressLevelBasic, log, log)
+}
+
+// Create the config data for an agent in tests, using the in-memory bus and repository named after the test.
+// Extra config lines, such as additional sections, are added at the end.
+func createTestConfigData(t *testing.T, agentID string, extraConfig ...string) *generics.TConfigData {
+	config := fmt.Sprintf("environment = test\nagent = %s\nwork_folder = %s\n\n"+
+		"[events]\nkind = memory\n\n"+
+		"[repository]\nkind = memory\n\n"+
+		"[memory]\nname = %s\n\n", agentID, t.TempDir(), t.Name())
+
+	configData, err := generics.TryLoadConfigFromData([]byte(config + strings.Join(extraConfig, "\n")))
+	if err != nil {
+		t.Fatalf("loading the config data: %s", err)
+	}
+
+	return configData
+}
+
+// Create a connector for an agent in tests, which is closed when the test ends
+func createTestConnector(t *testing.T, agentID string, extraConfig ...string) TModellingBusConnector {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	connector, err := CreateModellingBusConnectorContext(ctx, createTestConfigData(t, agentID, extraConfig...), createTestReporter(t), false)
+	if err != nil {
+		t.Fatalf("creating the connector: %s", err)
+	}
+
+	t.Cleanup(func() {
+		connector.Close(context.Background())
+	})
+
+	return connector
+}
+
+// Receive the next posting from a posting stream, failing when it does not arrive in time
+func receiveTestPosting[T any](t *testing.T, stream *TPostingStream[T]) T {
+	t.Helper()
+
+	select {
+	case posting := <-stream.C:
+		return posting
+	case <-time.After(testTimeout):
+		t.Fatal("no posting arrived in time")
+	}
+
+	var none T
+	return none
+}
+
+func TestInMemoryJSONObservationRoundTrip(t *testing.T) {
+	poster := createTestConnector(t, "poster")
+	listener := createTestConnector(t, "listener")
+
+	if err := poster.TryPostJSONObservation("temperature", []byte(`{"celsius":21}`)); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+
+	json, _, err := listener.TryGetJSONObservation("poster", "temperature")
+	if err != nil {
+		t.Fatalf("getting: %s", err)
+	}
+	if string(json) != `{"celsius":21}` {
+		t.Errorf("got %s", json)
+	}
+}
+
+func TestInMemoryMissingPosting(t *testing.T) {
+	listener := createTestConnector(t, "listener")
+
+	if _, _, err := listener.TryGetJSONObservation("poster", "temperature"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInMemoryListening(t *testing.T) {
+	poster := createTestConnector(t, "poster")
+	listener := createTestConnector(t, "listener")
+
+	stream := listener.ListenForStreamedObservationPostingsStream("poster", "clicks", TStreamOptions{})
+	defer stream.Unsubscribe()
+
+	for _, click := range []string{`1`, `2`, `3`} {
+		if err := poster.TryPostStreamedObservation("clicks", []byte(click)); err != nil {
+			t.Fatalf("posting: %s", err)
+		}
+
+		if posting := receiveTestPosting(t, stream); string(posting.JSON) != click {
+			t.Errorf("expected %s, got %s", click, posting.JSON)
+		}
+	}
+}
+
+func TestInMemoryArtefactRoundTrip(t *testing.T) {
+	poster := CreateModellingBusArtefactConnector(createTestConnector(t, "poster"), "v1")
+	listener := CreateModellingBusArtefactConnector(createTestConnector(t, "listener"), "v1")
+
+	poster.PrepareForPosting("model")
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactConsidering([]byte(`{"a":2,"b":3}`), nil); err != nil {
+		t.Fatalf("posting the considering: %s", err)
+	}
+
+	if err := listener.TryGetJSONArtefactConsidering("poster", "model"); err != nil {
+		t.Fatalf("getting: %s", err)
+	}
+	if string(listener.CurrentContent) != `{"a":1}` {
+		t.Errorf("current content: %s", listener.CurrentContent)
+	}
+	if string(listener.UpdatedContent) != `{"a":2}` {
+		t.Errorf("updated content: %s", listener.UpdatedContent)
+	}
+	if string(listener.ConsideredContent) != `{"a":2,"b":3}` {
+		t.Errorf("considered content: %s", listener.ConsideredContent)
+	}
+}
+
+func TestInMemoryDeletion(t *testing.T) {
+	poster := createTestConnector(t, "poster")
+
+	if err := poster.TryPostJSONObservation("temperature", []byte(`{"celsius":21}`)); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+	if err := poster.TryDeleteJSONObservation("temperature"); err != nil {
+		t.Fatalf("deleting: %s", err)
+	}
+
+	if _, _, err := poster.TryGetJSONObservation("poster", "temperature"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInMemoryBusAndRepositoryAreReleasedWhenClosed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	connector, err := CreateModellingBusConnectorContext(ctx, createTestConfigData(t, "poster"), createTestReporter(t), false)
+	if err != nil {
+		t.Fatalf("creating the connector: %s", err)
+	}
+	if err := connector.TryPostJSONObservation("temperature", []byte(`{"celsius":21}`)); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+
+	// Once the last connector using them has been closed
+	if err := connector.Close(ctx); err != nil {
+		t.Fatalf("closing: %s", err)
+	}
+
+	inMemoryBusesMutex.Lock()
+	_, busDefined := inMemoryBuses[t.Name()]
+	inMemoryBusesMutex.Unlock()
+	if busDefined {
+		t.Error("the in-memory bus has not been released")
+	}
+
+	inMemoryRepositoriesMutex.Lock()
+	_, repositoryDefined := inMemoryRepositories[t.Name()]
+	inMemoryRepositoriesMutex.Unlock()
+	if repositoryDefined {
+		t.Error("the in-memory repository has not been released")
+	}
+}
+
+func TestInMemoryRepositoryURLs(t *testing.T) {
+	for _, name := range []string{"default", "TestInMemory/sub_test", "host:port", "with space", "50%"} {
+		t.Run(name, func(t *testing.T) {
+			repositoryEvent := tRepositoryEvent{URL: memoryURLFor(name, "/test/file.json")}
+			if repositoryEvent.scheme() != memoryURLScheme {
+				t.Errorf("expected the %s scheme for %s", memoryURLScheme, repositoryEvent.URL)
+			}
+
+			parsedURL, err := url.Parse(repositoryEvent.URL)
+			if err != nil {
+				t.Fatalf("parsing %s: %s", repositoryEvent.URL, err)
+			}
+			if parsedURL.Query().Get("name") != name || parsedURL.Path != "/test/file.json" {
+				t.Errorf("expected %s and /test/file.json, got %s", name, repositoryEvent.URL)
+			}
+		})
+	}
+}
@@ -4,44 +4,77 @@
  * Package:   Connect
  * Component: Layer 1 - Repository Connector
  *
- * This component provides the connectivity to the FTP-based repository.
+ * This component provides the connectivity to the repository.
+ * The actual storage of files is provided by a repository backend, which is selected by the "kind" key in the
+ * "repository" section of the config file. By default, the FTP-based repository backend is used. The "prefix" key
+ * of the "repository" section sets the prefix of the paths in the repository, whichever backend is used.
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 29.11.2025
+ * Version of: 16.10.2026
  *
  */
 
 package connect
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
-	"github.com/secsy/goftp"
 )
 
+/*
+ * Defining repository backends
+ */
+
+type (
+	// A repository backend provides the actual storage of files in the repository.
+	// Remote file paths are always full paths, including the prefix and modelling environment.
+	tRepositoryBackend interface {
+		storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) // Store a file in the repository
+		retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error       // Retrieve a file from the repository
+		deletePath(remotePath string) error                                        // Delete a file or directory tree from the repository
+		scheme() string                                                            // The URL scheme of the files stored by the backend
+		release()                                                                  // Release the resources held by the backend, once it is no longer used
+	}
+
+	// Creator of a repository backend, based on the config data, returning an error when the config data is not valid
+	tRepositoryBackendCreator func(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error)
+)
+
+const (
+	defaultRepositoryBackendKind = ftpRepositoryBackendKind // The kind of repository backend used when none is configured
+)
+
+// The known repository backend kinds
+var repositoryBackendCreators = map[string]tRepositoryBackendCreator{}
+
 /*
  * Defining the repository connector
  */
 
 type (
 	tModellingBusRepositoryConnector struct {
-		port, // FTP port
-		user, // FTP user
-		server, // FTP server
-		prefix, // FTP topic prefix
-		agentID, // Agent ID to be used in postings on the FTP repository
-		password, // FTP password
+		prefix, // Repository topic prefix
+		agentID, // Agent ID to be used in postings on the repository
 		environmentID, // Modelling environment ID
-		localWorkDirectory string // Local work directory
+		localWorkDirectory, // Local work directory
+		fileRootDirectory string // Root directory of the filesystem repository from which "file://" URLs may be read, if any
 
-		activeTransfers, // Whether to use active transfers for FTP
-		singleServerMode bool // Whether to use a single FTP server for all agents and environments
+		httpHosts map[string]bool // Hosts of the HTTP(S) servers of the repository, from which files may be downloaded
 
-		createdPaths map[string]bool // Paths already created on the FTP server
+		backend tRepositoryBackend // The repository backend
+
+		temporaryFilesMutex sync.Mutex      // Guards the temporary files
+		temporaryFiles      map[string]bool // Local files retrieved from the repository into the work directory
 
 		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
 	}
@@ -51,13 +84,57 @@ type (
  * Defining repository events
  */
 
+// A repository event announces the location of a file in the repository by means of a URL, such as
+// "ftp://server:port/path", "file:///path" or "https://server/path".
+// Depending on the URL scheme, the server, port and file path are provided as separate fields as well.
+// Older versions of the modelling bus only provided the server, port and file path of files on an FTP
+// server. When decoding such repository events, the URL is derived from these fields.
 type tRepositoryEvent struct {
-	Server    string `json:"server,omitempty"`    // FTP server for the file
-	Port      string `json:"port,omitempty"`      // FTP port on the FTP server
-	FilePath  string `json:"file path,omitempty"` // Path to the file on the FTP server
+	URL       string `json:"url,omitempty"`       // Location of the file
+	Server    string `json:"server,omitempty"`    // Server for the file (FTP, SFTP)
+	Port      string `json:"port,omitempty"`      // Port on the server (FTP, SFTP)
+	FilePath  string `json:"file path,omitempty"` // Path to the file on the server (FTP, SFTP), or within the repository
 	Timestamp string `json:"timestamp"`           // Timestamp of the event
 }
 
+// Get the URL for a file on a server, leaving out the server when it is not known
+func serverURLFor(scheme, server, port, filePath string) string {
+	host := server
+	if server != "" && port != "" {
+		host = server + ":" + port
+	}
+
+	return (&url.URL{Scheme: scheme, Host: host, Path: "/" + strings.TrimPrefix(filePath, "/")}).String()
+}
+
+// Decode a repository event, deriving the URL for repository events from older versions of the modelling bus
+func (e *tRepositoryEvent) UnmarshalJSON(data []byte) error {
+	// Decode the repository event as such
+	type tPlainRepositoryEvent tRepositoryEvent
+	plainRepositoryEvent := tPlainRepositoryEvent{}
+	err := json.Unmarshal(data, &plainRepositoryEvent)
+	if err != nil {
+		return err
+	}
+	*e = tRepositoryEvent(plainRepositoryEvent)
+
+	// Older repository events always referred to files on an FTP server
+	if e.URL == "" && e.FilePath != "" {
+		e.URL = serverURLFor(ftpURLScheme, e.Server, e.Port, e.FilePath)
+	}
+
+	return nil
+}
+
+// Get the URL scheme of a repository event
+func (e *tRepositoryEvent) scheme() string {
+	if parsedURL, err := url.Parse(e.URL); err == nil {
+		return parsedURL.Scheme
+	}
+
+	return ""
+}
+
 /*
  * Defining topic paths and file paths
  */
@@ -67,6 +144,11 @@ func (r *tModellingBusRepositoryConnector) localFilePathFor(fileName string) str
 	return filepath.FromSlash(r.localWorkDirectory + "/" + fileName)
 }
 
+// Get a unique name for a temporary JSON file, so that several goroutines can handle JSON files at the same time
+func temporaryJSONFileName() string {
+	return generics.GetTimestamp() + "-" + generics.JSONFileName
+}
+
 // Get the topic root for the given modelling environment
 func (r *tModellingBusRepositoryConnector) ftpEnvironmentTopicRootFor(environmentID string) string {
 	return r.prefix + "/" + generics.ModellingBusVersion + "/" + environmentID
@@ -78,237 +160,226 @@ func (r *tModellingBusRepositoryConnector) ftpTopicPath(topicPath string) string
 }
 
 /*
- * FTP connection and operations
+ * Repository operations
  */
 
-// Connecting to the FTP server
-func (r *tModellingBusRepositoryConnector) ftpConnect() (*goftp.Client, error) {
-	// Define the FTP connection configuration
-	config := goftp.Config{}
-	config.User = r.user
-	config.Password = r.password
-	config.ActiveTransfers = r.activeTransfers
-	serverDefinition := r.server + ":" + r.port
-
-	// Finally, connect to the FTP server
-	client, err := goftp.DialConfig(config, serverDefinition)
-	if err != nil {
-		r.reporter.Error("Error connecting to the FTP server. %s", err)
-		return client, err
-	}
-
-	// Return the connected client
-	return client, err
-}
-
-// Make sure the given repository file path exists on the FTP server
-func (r *tModellingBusRepositoryConnector) mkRepositoryFilePath(remoteFilePath string) {
-	// Create the path on the FTP server, if not already done
-	if !r.createdPaths[remoteFilePath] {
-		// Connect to the FTP server
-		if client, err := r.ftpConnect(); err == nil {
-			pathCovered := ""
-			// Create all directories in the path, if not already existing
-			for _, Directory := range strings.Split(remoteFilePath, "/") {
-				pathCovered = pathCovered + Directory + "/"
-				client.Mkdir(pathCovered)
-			}
-
-			// Close the FTP connection
-			client.Close()
-
-			// Mark the path as created
-			r.createdPaths[remoteFilePath] = true
-		}
-	}
+// Add a file to the repository
+func (r *tModellingBusRepositoryConnector) addFile(topicPath, localFilePath, timestamp string) (tRepositoryEvent, error) {
+	return r.addFileNamed(topicPath, generics.PayloadFileName, localFilePath, timestamp)
 }
 
-// Add a file to the repository
-func (r *tModellingBusRepositoryConnector) addFile(topicPath, localFilePath, timestamp string) tRepositoryEvent {
+// Add a file to the repository, under a given file name for the topic path
+func (r *tModellingBusRepositoryConnector) addFileNamed(topicPath, fileName, localFilePath, timestamp string) (tRepositoryEvent, error) {
 	// Define the remote file path
-	remoteFilePath := r.ftpTopicPath(topicPath)
-	remotePayloadFileNamePath := remoteFilePath + "/" + generics.PayloadFileName
-
-	// Make sure the path exists on the FTP server
-	r.mkRepositoryFilePath(remoteFilePath)
-
-	// Upload the file to the FTP server
-	repositoryEvent := tRepositoryEvent{}
-	repositoryEvent.Timestamp = timestamp
+	remotePayloadFileNamePath := r.ftpTopicPath(topicPath) + "/" + fileName
 
 	// Open the local file for reading
 	file, err := os.Open(filepath.FromSlash(localFilePath))
 	if err != nil {
-		r.reporter.Error("Error opening File for reading. %s", err)
-		return repositoryEvent
+		return tRepositoryEvent{Timestamp: timestamp}, fmt.Errorf("opening file for reading: %w", err)
 	}
 
-	// Connect to the FTP server
-	client, err := r.ftpConnect()
-	if err != nil {
-		return repositoryEvent
-	}
+	// Close the local file afterwards
+	defer file.Close()
 
-	// Store the file on the FTP server
-	err = client.Store(remotePayloadFileNamePath, file)
+	// Store the file in the repository
+	repositoryEvent, err := r.backend.storeFile(remotePayloadFileNamePath, file)
+	repositoryEvent.Timestamp = timestamp
 
 	// Handle potential errors
 	if err != nil {
-		r.reporter.Error("Error uploading file to ftp server. %s", err)
-		r.reporter.Error("For remote file path: %s", remotePayloadFileNamePath)
-		return repositoryEvent
-	}
-
-	// Close the local file
-	client.Close()
-
-	// Define the repository event
-	if !r.singleServerMode {
-		repositoryEvent.Server = r.server
-		repositoryEvent.Port = r.port
+		return repositoryEvent, repositoryError(fmt.Errorf("uploading %s to the repository: %w", remotePayloadFileNamePath, err))
 	}
-	repositoryEvent.FilePath = remotePayloadFileNamePath
 
 	// Return the repository event
-	return repositoryEvent
+	return repositoryEvent, nil
 }
 
-// Delete a path from the repository
-func deleteRepositoryPath(client *goftp.Client, deletePath string) {
-	// We're not certain if deletePath refers to a file or a directory.
-
-	// So first, we try to read it as a directory.
-	fileInfos, _ := client.ReadDir(deletePath)
-	if len(fileInfos) > 0 {
-		// If it works, we delete all contents recursively, then remove the directory itself.
-		for _, fileInfo := range fileInfos {
-			deleteRepositoryPath(client, deletePath+"/"+fileInfo.Name())
-		}
-		client.Rmdir(deletePath)
-	} else {
-		// If it fails, we assume it's a file and delete it directly.
-		client.Delete(deletePath)
+func (r *tModellingBusRepositoryConnector) deletePath(deletePath string) error {
+	// Delete the given path from the repository
+	err := r.backend.deletePath(deletePath)
+	if err != nil {
+		return repositoryError(fmt.Errorf("deleting path %s from the repository: %w", deletePath, err))
 	}
+
+	return nil
 }
 
-func (r *tModellingBusRepositoryConnector) deletePath(deletePath string) {
-	// Connect to the FTP server
-	if client, err := r.ftpConnect(); err == nil {
-		// Then, delete the given path from the FTP server
-		deleteRepositoryPath(client, deletePath)
-	}
+func (r *tModellingBusRepositoryConnector) deletePostingPath(topicPath string) error {
+	// Delete the path from the repository for the given topic path
+	return r.deletePath(r.ftpTopicPath(topicPath))
 }
 
-func (r *tModellingBusRepositoryConnector) deletePostingPath(topicPath string) {
-	// Delete the path from the FTP server for the given topic path
-	r.deletePath(r.ftpTopicPath(topicPath))
+func (r *tModellingBusRepositoryConnector) deleteEnvironment(environment string) error {
+	// Delete the entere file tree from the repository for the given environment
+	return r.deletePath(r.ftpEnvironmentTopicRootFor(environment))
 }
 
-func (r *tModellingBusRepositoryConnector) deleteEnvironment(environment string) {
-	// Delete the entere file tree from the FTP server for the given environment
-	r.deletePath(r.ftpEnvironmentTopicRootFor(environment))
+func (r *tModellingBusRepositoryConnector) addJSONAsFile(topicPath string, json []byte, timestamp string) (tRepositoryEvent, error) {
+	return r.addJSONAsFileNamed(topicPath, generics.PayloadFileName, json, timestamp)
 }
 
-func (r *tModellingBusRepositoryConnector) addJSONAsFile(topicPath string, json []byte, timestamp string) tRepositoryEvent {
+// Add JSON to the repository, under a given file name for the topic path
+func (r *tModellingBusRepositoryConnector) addJSONAsFileNamed(topicPath, fileName string, json []byte, timestamp string) (tRepositoryEvent, error) {
 	// Define the temporary local file path
-	localFilePath := r.localFilePathFor(generics.JSONFileName)
+	localFilePath := r.localFilePathFor(temporaryJSONFileName())
 
 	// Create a temporary local file with the JSON record
 	err := os.WriteFile(localFilePath, json, 0644)
 	if err != nil {
-		r.reporter.Error("Error writing to temporary file. %s", err)
+		return tRepositoryEvent{Timestamp: timestamp}, fmt.Errorf("writing to temporary file: %w", err)
 	}
 
 	// Cleanup the temporary file afterwards
 	defer os.Remove(localFilePath)
 
 	// Add the file to the repository
-	return r.addFile(topicPath, localFilePath, timestamp)
+	return r.addFileNamed(topicPath, fileName, localFilePath, timestamp)
 }
 
-func (r *tModellingBusRepositoryConnector) getFile(repositoryEvent tRepositoryEvent, fileName string) string {
-	// Configure FTP connection
-	config := goftp.Config{}
-	config.ActiveTransfers = r.activeTransfers
-	serverConnection := ""
+// Retrieve a file from an HTTP(S) URL, as long as it is on one of the servers of the repository
+func (r *tModellingBusRepositoryConnector) retrieveFileFromHTTPURL(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Only download files from the servers of the repository
+	fileURL, err := url.Parse(repositoryEvent.URL)
+	if err != nil {
+		return err
+	}
+	if !r.httpHosts[fileURL.Host] {
+		return fmt.Errorf("%s is not a server of the repository", fileURL.Host)
+	}
+
+	// Files from an HTTP repository are retrieved by the backend, which knows when to provide its credentials
+	if fileURL.Scheme == r.backend.scheme() {
+		return r.backend.retrieveFile(repositoryEvent, file)
+	}
 
-	// Determine server connection details
-	if r.singleServerMode {
-		serverConnection = r.server + ":" + r.port
+	// Other files (such as pre-signed URLs to an object store) can be downloaded directly
+	err = retrieveFileFromHTTPURL(http.DefaultClient, repositoryEvent.URL, file)
+	if err == nil {
+		return nil
+	}
 
-		config.User = r.user
-		config.Password = r.password
-	} else {
-		serverConnection = repositoryEvent.Server + ":" + repositoryEvent.Port
+	// Pre-signed URLs to our own object store may have expired, in which case we use our own credentials
+	s3Backend, isS3Backend := r.backend.(*tS3RepositoryBackend)
+	if isS3Backend && repositoryEvent.FilePath != "" && s3Backend.isOwnURL(repositoryEvent.URL) {
+		return s3Backend.retrieveFile(repositoryEvent, file)
 	}
 
-	// Connect to the FTP server
-	client, err := goftp.DialConfig(config, serverConnection)
-	if err != nil {
-		r.reporter.Error("Something went wrong connecting to the FTP server: \"%s\"", err)
-		return ""
+	return err
+}
+
+// Retrieve a file, based on the URL scheme of the repository event
+func (r *tModellingBusRepositoryConnector) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	switch scheme := repositoryEvent.scheme(); {
+	case scheme == httpURLScheme || scheme == httpsURLScheme:
+		// Files on a web server can be downloaded directly, as long as they are on one of the configured ones
+		return r.retrieveFileFromHTTPURL(repositoryEvent, file)
+
+	case scheme == r.backend.scheme():
+		// Files from the same kind of repository are retrieved by the backend
+		return r.backend.retrieveFile(repositoryEvent, file)
+
+	case scheme == fileURLScheme:
+		// Files from a filesystem repository can be read directly, as long as they are in the configured one
+		return retrieveFileFromFileURL(r.fileRootDirectory, repositoryEvent.URL, file)
+
+	default:
+		return fmt.Errorf("cannot retrieve \"%s\" URLs using a \"%s\" repository", scheme, r.backend.scheme())
 	}
+}
 
+func (r *tModellingBusRepositoryConnector) getFile(repositoryEvent tRepositoryEvent, fileName string) (string, error) {
 	// Set local file path
 	localFileName := r.localFilePathFor(fileName)
 
 	// Download file to local storage
 	File, err := os.Create(localFileName)
 	if err != nil {
-		r.reporter.Error("Something went wrong creating local file: \"%s\"", err)
-		return ""
+		return "", fmt.Errorf("creating local file: %w", err)
 	}
 
+	// Remember the file, so it can be removed when closing
+	r.temporaryFilesMutex.Lock()
+	r.temporaryFiles[localFileName] = true
+	r.temporaryFilesMutex.Unlock()
+
 	// Ensure the file is closed after operation
 	defer File.Close()
 
-	// Retrieve the file from the FTP server
-	err = client.Retrieve(repositoryEvent.FilePath, File)
+	// Retrieve the file
+	err = r.retrieveFile(repositoryEvent, File)
 	if err != nil {
-		r.reporter.Error("Something went wrong retrieving file: \"%s\"", err)
-		r.reporter.Error("Was trying to retrieve: %s", repositoryEvent.FilePath)
-		return ""
+		return "", repositoryError(fmt.Errorf("retrieving %s: %w", repositoryEvent.URL, err))
 	}
 
 	// Return the local file name
-	return localFileName
+	return localFileName, nil
+}
+
+// Close the repository connector, releasing the repository backend and removing the temporary files
+func (r *tModellingBusRepositoryConnector) close() {
+	r.backend.release()
+	r.removeTemporaryFiles()
 }
 
-func createModellingBusRepositoryConnector(environmentID, agentID string, configData *generics.TConfigData, reporter *generics.TReporter) *tModellingBusRepositoryConnector {
+// Remove the files retrieved from the repository into the work directory
+func (r *tModellingBusRepositoryConnector) removeTemporaryFiles() {
+	r.temporaryFilesMutex.Lock()
+	defer r.temporaryFilesMutex.Unlock()
+
+	for localFileName := range r.temporaryFiles {
+		err := os.Remove(localFileName)
+		if err != nil && !os.IsNotExist(err) {
+			r.reporter.Error("Could not remove temporary file. %s", err)
+		}
+	}
+	r.temporaryFiles = map[string]bool{}
+}
+
+func createModellingBusRepositoryConnector(environmentID, agentID string, configData *generics.TConfigData, reporter *generics.TReporter) (*tModellingBusRepositoryConnector, error) {
 	// Create the repository connector
 	r := tModellingBusRepositoryConnector{}
 
 	// Get data from the config file
 	r.localWorkDirectory = configData.GetValue("", "work_folder").String()
-	r.port = configData.GetValue("ftp", "port").String()
-	r.user = configData.GetValue("ftp", "user").String()
-	r.server = configData.GetValue("ftp", "server").String()
-	r.password = configData.GetValue("ftp", "password").String()
-	r.singleServerMode = configData.GetValue("ftp", "single_server_mode").BoolWithDefault(false)
-	r.activeTransfers = configData.GetValue("ftp", "active_transfers").BoolWithDefault(false)
-	r.prefix = configData.GetValue("ftp", "prefix").String()
+	kind := configData.GetValue("repository", "kind").StringWithDefault(defaultRepositoryBackendKind)
+
+	// The prefix is configured for the repository as a whole, while older config files provide it in the section
+	// of the repository backend, or, from the time when only FTP was supported, in the "ftp" section
+	r.prefix = configData.GetValue("repository", "prefix").StringWithDefault(
+		configData.GetValue(kind, "prefix").StringWithDefault(
+			configData.GetValue("ftp", "prefix").String()))
+
+	// Select the repository backend
+	createRepositoryBackend, known := repositoryBackendCreators[kind]
+	if !known {
+		return nil, fmt.Errorf("%w: unknown kind of repository backend: %s", ErrInvalidConfig, kind)
+	}
+	backend, err := createRepositoryBackend(configData, reporter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating the %s repository backend: %w", ErrInvalidConfig, kind, err)
+	}
+	r.backend = backend
+
+	// The filesystem repository may also be shared with agents using another kind of repository backend
+	fileRootDirectory, err := filesystemRootDirectoryFromConfig(configData)
+	if err != nil {
+		reporter.Error("Could not determine the root directory of the filesystem repository. %s", err)
+	}
+	r.fileRootDirectory = fileRootDirectory
+
+	// The same holds for the HTTP repository, and the object store of the S3 repository
+	r.httpHosts = map[string]bool{}
+	for _, host := range append(httpRepositoryHostsFromConfig(configData), s3RepositoryHostsFromConfig(configData)...) {
+		r.httpHosts[host] = true
+	}
 
 	// Initialising other data
-	r.reporter = reporter
 	r.agentID = agentID
 	r.environmentID = environmentID
+	r.temporaryFiles = map[string]bool{}
 	r.reporter = reporter
-	r.createdPaths = map[string]bool{}
-
-	// Reporting on the configuration
-	if r.singleServerMode {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in single server mode.")
-	} else {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in multi server mode.")
-	}
-
-	// Reporting on the transfer mode
-	if r.activeTransfers {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in active transfer mode.")
-	} else {
-		r.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in passive transfer mode.")
-	}
 
 	// Return the created repository connector
-	return &r
+	return &r, nil
 }
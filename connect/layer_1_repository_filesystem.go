@@ -0,0 +1,234 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository Filesystem
+ *
+ * This component provides a repository backend based on a (local or shared, e.g. NFS mounted) directory.
+ * The files are stored using the same layout as on the FTP server, but underneath a root directory.
+ * The repository events refer to the files by means of a "file://" URL, so listeners can read the files
+ * directly, without needing any credentials. As these URLs are provided by the posting agents, listeners only
+ * read files from underneath the root directory of their own configuration.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	filesystemRepositoryBackendKind = "filesystem" // Kind of the filesystem-based repository backend
+	fileURLScheme                   = "file"       // URL scheme for files in a filesystem-based repository
+)
+
+/*
+ * Defining file URLs
+ */
+
+// Get the file URL for a given local file path
+func fileURLFor(localFilePath string) string {
+	urlPath := filepath.ToSlash(localFilePath)
+
+	// Paths with a volume name, such as "C:/...", still need a leading "/"
+	if !strings.HasPrefix(urlPath, "/") {
+		urlPath = "/" + urlPath
+	}
+
+	return (&url.URL{Scheme: fileURLScheme, Path: urlPath}).String()
+}
+
+// Get the local file path for a given file URL
+func localFilePathFromFileURL(fileURL string) (string, error) {
+	parsedURL, err := url.Parse(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Paths with a volume name, such as "/C:/...", should not have a leading "/"
+	urlPath := parsedURL.Path
+	if filepath.VolumeName(strings.TrimPrefix(urlPath, "/")) != "" {
+		urlPath = strings.TrimPrefix(urlPath, "/")
+	}
+
+	return filepath.FromSlash(urlPath), nil
+}
+
+// Check whether a local file path lies underneath a given root directory
+func localFilePathIsWithin(rootDirectory, localFilePath string) bool {
+	if rootDirectory == "" {
+		return false
+	}
+
+	relativePath, err := filepath.Rel(rootDirectory, filepath.Clean(localFilePath))
+
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// Retrieve a file given its file URL, which needs to refer to a file underneath the given root directory
+func retrieveFileFromFileURL(rootDirectory, fileURL string, file io.Writer) error {
+	// Determine the local file path
+	localFilePath, err := localFilePathFromFileURL(fileURL)
+	if err != nil {
+		return err
+	}
+
+	// Only files in the repository may be retrieved
+	if !localFilePathIsWithin(rootDirectory, localFilePath) {
+		return fmt.Errorf("%s is not in the filesystem repository", fileURL)
+	}
+
+	// Open the file for reading
+	sourceFile, err := os.Open(localFilePath)
+	if err != nil {
+		return err
+	}
+
+	// Close the file afterwards
+	defer sourceFile.Close()
+
+	// Copy the file
+	_, err = io.Copy(file, sourceFile)
+
+	return err
+}
+
+/*
+ * Defining the filesystem repository backend
+ */
+
+type (
+	tFilesystemRepositoryBackend struct {
+		rootDirectory string // The root directory of the repository
+	}
+)
+
+// Get the local file path for a given remote file path
+func (f *tFilesystemRepositoryBackend) localFilePathFor(remoteFilePath string) string {
+	return filepath.Join(f.rootDirectory, filepath.FromSlash(remoteFilePath))
+}
+
+// Store a file in the repository directory
+func (f *tFilesystemRepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	repositoryEvent := tRepositoryEvent{}
+	localFilePath := f.localFilePathFor(remoteFilePath)
+
+	// Make sure the directory exists
+	err := os.MkdirAll(filepath.Dir(localFilePath), 0755)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Write to a temporary file first, so listeners never read a partially written file
+	temporaryFile, err := os.CreateTemp(filepath.Dir(localFilePath), "."+generics.PayloadFileName+"-*")
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Copy the file
+	_, err = io.Copy(temporaryFile, file)
+	closeErr := temporaryFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temporaryFile.Name())
+		return repositoryEvent, err
+	}
+
+	// Make the file readable for other agents
+	err = os.Chmod(temporaryFile.Name(), 0644)
+	if err == nil {
+		// Move the temporary file into place
+		err = os.Rename(temporaryFile.Name(), localFilePath)
+	}
+	if err != nil {
+		os.Remove(temporaryFile.Name())
+		return repositoryEvent, err
+	}
+
+	// Define the repository event
+	repositoryEvent.FilePath = remoteFilePath
+	repositoryEvent.URL = fileURLFor(localFilePath)
+
+	// Return the repository event
+	return repositoryEvent, nil
+}
+
+// Retrieve a file from the repository directory
+func (f *tFilesystemRepositoryBackend) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Prefer the file URL, as it is independent of the configuration of the listener
+	if repositoryEvent.URL != "" {
+		return retrieveFileFromFileURL(f.rootDirectory, repositoryEvent.URL, file)
+	}
+
+	return retrieveFileFromFileURL(f.rootDirectory, fileURLFor(f.localFilePathFor(repositoryEvent.FilePath)), file)
+}
+
+// Delete a file or directory tree from the repository directory
+func (f *tFilesystemRepositoryBackend) deletePath(remotePath string) error {
+	return os.RemoveAll(f.localFilePathFor(remotePath))
+}
+
+// The URL scheme of the files in the repository directory
+func (f *tFilesystemRepositoryBackend) scheme() string {
+	return fileURLScheme
+}
+
+// Nothing to release, as files are only opened during an operation
+func (f *tFilesystemRepositoryBackend) release() {
+}
+
+/*
+ * Creating filesystem repository backends
+ */
+
+// Get the absolute root directory of the filesystem repository from the config data, which is empty when none
+// has been configured. Being absolute, the file URLs can be used by other agents as well.
+func filesystemRootDirectoryFromConfig(configData *generics.TConfigData) (string, error) {
+	rootDirectory := configData.GetValue("filesystem", "root").String()
+	if rootDirectory == "" {
+		return "", nil
+	}
+
+	return filepath.Abs(rootDirectory)
+}
+
+// Create a filesystem repository backend
+func createFilesystemRepositoryBackend(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error) {
+	// Create the repository backend
+	f := tFilesystemRepositoryBackend{}
+
+	// Get data from the config file
+	rootDirectory, err := filesystemRootDirectoryFromConfig(configData)
+	if err != nil {
+		return nil, fmt.Errorf("determining the root directory of the filesystem repository: %w", err)
+	}
+	if rootDirectory == "" {
+		return nil, errors.New("no root directory configured for the filesystem repository")
+	}
+	f.rootDirectory = rootDirectory
+
+	// Report on the configuration
+	reporter.Progress(generics.ProgressLevelDetailed, "Using filesystem repository at: %s", f.rootDirectory)
+
+	// Return the created repository backend
+	return &f, nil
+}
+
+func init() {
+	repositoryBackendCreators[filesystemRepositoryBackendKind] = createFilesystemRepositoryBackend
+}
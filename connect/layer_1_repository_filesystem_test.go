@@ -0,0 +1,103 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository Filesystem (tests)
+ *
+ * These tests store and retrieve files by means of the filesystem repository backend.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+// Create a repository connector for tests, using the given repository config
+func createTestRepositoryConnector(t *testing.T, repositoryConfig string) *tModellingBusRepositoryConnector {
+	configData, err := generics.TryLoadConfigFromData([]byte("work_folder = " + t.TempDir() + "\n\n" + repositoryConfig))
+	if err != nil {
+		t.Fatalf("loading the config data: %s", err)
+	}
+
+	repositoryConnector, err := createModellingBusRepositoryConnector("test", "agent", configData, createTestReporter(t))
+	if err != nil {
+		t.Fatalf("creating the repository connector: %s", err)
+	}
+
+	return repositoryConnector
+}
+
+func TestFilesystemRepositoryRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	repository := createTestRepositoryConnector(t, "[repository]\nkind = filesystem\n\n[filesystem]\nroot = "+root)
+
+	event, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{"celsius":21}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+	if !strings.HasPrefix(event.URL, "file://") {
+		t.Errorf("expected a file URL, got %s", event.URL)
+	}
+
+	file := bytes.Buffer{}
+	if err := repository.retrieveFile(event, &file); err != nil {
+		t.Fatalf("retrieving: %s", err)
+	}
+	if file.String() != `{"celsius":21}` {
+		t.Errorf("got %s", file.String())
+	}
+}
+
+func TestFilesystemRepositoryRefusesFilesOutsideRoot(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	for name, repositoryConfig := range map[string]string{
+		"filesystem": "[repository]\nkind = filesystem\n\n[filesystem]\nroot = " + root,
+		"memory":     "[repository]\nkind = memory\n\n[memory]\nname = " + t.Name() + "\n\n[filesystem]\nroot = " + root,
+		"no root":    "[repository]\nkind = memory\n\n[memory]\nname = " + t.Name(),
+	} {
+		repository := createTestRepositoryConnector(t, repositoryConfig)
+
+		for _, fileURL := range []string{fileURLFor(secret), fileURLFor(filepath.Join(root, "..", filepath.Base(filepath.Dir(secret)), "secret"))} {
+			file := bytes.Buffer{}
+			if err := repository.retrieveFile(tRepositoryEvent{URL: fileURL}, &file); err == nil || file.Len() > 0 {
+				t.Errorf("%s: retrieved %s", name, fileURL)
+			}
+		}
+	}
+}
+
+func TestRepositoryPrefix(t *testing.T) {
+	root := t.TempDir()
+	for name, prefixConfig := range map[string]string{
+		"repository": "[repository]\nkind = filesystem\nprefix = bus\n\n[filesystem]\nroot = " + root + "\nprefix = other",
+		"backend":    "[repository]\nkind = filesystem\n\n[filesystem]\nroot = " + root + "\nprefix = bus",
+		"legacy":     "[repository]\nkind = filesystem\n\n[filesystem]\nroot = " + root + "\n\n[ftp]\nprefix = bus",
+	} {
+		repository := createTestRepositoryConnector(t, prefixConfig)
+
+		event, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{}`), "now")
+		if err != nil {
+			t.Fatalf("%s: adding: %s", name, err)
+		}
+		if !strings.HasPrefix(event.URL, fileURLFor(filepath.Join(root, "bus"))+"/") {
+			t.Errorf("%s: expected the file underneath the prefix, got %s", name, event.URL)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository FTP
+ *
+ * This component provides the FTP-based repository backend, using the functionality of "github.com/secsy/goftp".
+ * It is the default repository backend of the repository connector.
+ * Using the "tls" key in the "ftp" section of the config file, the connection can be secured using explicit
+ * ("AUTH TLS") or implicit FTPS, where "tls_mode" selects between "explicit" (default) and "implicit".
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+	"github.com/secsy/goftp"
+)
+
+const (
+	ftpRepositoryBackendKind = "ftp" // Kind of the FTP-based repository backend
+	ftpURLScheme             = "ftp" // URL scheme for files on an FTP server
+	ftpFileUnavailable       = 550   // FTP reply code for files that are not available
+)
+
+/*
+ * Defining the FTP repository backend
+ */
+
+type (
+	tFTPRepositoryBackend struct {
+		port, // FTP port
+		user, // FTP user
+		server, // FTP server
+		password string // FTP password
+
+		activeTransfers, // Whether to use active transfers for FTP
+		singleServerMode bool // Whether to use a single FTP server for all agents and environments
+
+		tlsConfig *tls.Config   // TLS configuration for FTPS, if any
+		tlsMode   goftp.TLSMode // Explicit or implicit FTPS
+
+		createdPathsMutex sync.Mutex      // Guards the created paths
+		createdPaths      map[string]bool // Paths already created on the FTP server
+
+		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
+	}
+)
+
+/*
+ * FTP connection and operations
+ */
+
+// Add the FTPS settings for a given server to an FTP connection configuration
+func (f *tFTPRepositoryBackend) configureFTPS(config *goftp.Config, server string) {
+	// Without TLS, there is nothing to configure
+	if f.tlsConfig == nil {
+		return
+	}
+
+	// The server certificate needs to be verified against the name of the server
+	config.TLSConfig = f.tlsConfig.Clone()
+	if config.TLSConfig.ServerName == "" {
+		config.TLSConfig.ServerName = server
+	}
+	config.TLSMode = f.tlsMode
+}
+
+// Connecting to the FTP server
+func (f *tFTPRepositoryBackend) ftpConnect() (*goftp.Client, error) {
+	// Define the FTP connection configuration
+	config := goftp.Config{}
+	config.User = f.user
+	config.Password = f.password
+	config.ActiveTransfers = f.activeTransfers
+	f.configureFTPS(&config, f.server)
+	serverDefinition := f.server + ":" + f.port
+
+	// Finally, connect to the FTP server
+	client, err := goftp.DialConfig(config, serverDefinition)
+	if err != nil {
+		f.reporter.Error("Error connecting to the FTP server. %s", err)
+		return client, err
+	}
+
+	// Return the connected client
+	return client, err
+}
+
+// Make sure the given repository file path exists on the FTP server
+func (f *tFTPRepositoryBackend) mkRepositoryFilePath(remoteFilePath string) {
+	// Create the path on the FTP server, if not already done
+	f.createdPathsMutex.Lock()
+	created := f.createdPaths[remoteFilePath]
+	f.createdPathsMutex.Unlock()
+
+	if !created {
+		// Connect to the FTP server
+		if client, err := f.ftpConnect(); err == nil {
+			pathCovered := ""
+			// Create all directories in the path, if not already existing
+			for _, Directory := range strings.Split(remoteFilePath, "/") {
+				pathCovered = pathCovered + Directory + "/"
+				client.Mkdir(pathCovered)
+			}
+
+			// Close the FTP connection
+			client.Close()
+
+			// Mark the path as created
+			f.createdPathsMutex.Lock()
+			f.createdPaths[remoteFilePath] = true
+			f.createdPathsMutex.Unlock()
+		}
+	}
+}
+
+// Store a file on the FTP server
+func (f *tFTPRepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	repositoryEvent := tRepositoryEvent{}
+
+	// Make sure the path exists on the FTP server
+	f.mkRepositoryFilePath(path.Dir(remoteFilePath))
+
+	// Connect to the FTP server
+	client, err := f.ftpConnect()
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Close the FTP connection afterwards
+	defer client.Close()
+
+	// Store the file on the FTP server
+	err = client.Store(remoteFilePath, file)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Define the repository event
+	if !f.singleServerMode {
+		repositoryEvent.Server = f.server
+		repositoryEvent.Port = f.port
+	}
+	repositoryEvent.FilePath = remoteFilePath
+	repositoryEvent.URL = serverURLFor(ftpURLScheme, repositoryEvent.Server, repositoryEvent.Port, remoteFilePath)
+
+	// Return the repository event
+	return repositoryEvent, nil
+}
+
+// Retrieve a file from the FTP server
+func (f *tFTPRepositoryBackend) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Configure FTP connection
+	config := goftp.Config{}
+	config.ActiveTransfers = f.activeTransfers
+	serverConnection := ""
+
+	// Determine server connection details
+	if f.singleServerMode {
+		serverConnection = f.server + ":" + f.port
+
+		config.User = f.user
+		config.Password = f.password
+		f.configureFTPS(&config, f.server)
+	} else {
+		serverConnection = repositoryEvent.Server + ":" + repositoryEvent.Port
+		f.configureFTPS(&config, repositoryEvent.Server)
+	}
+
+	// Connect to the FTP server
+	client, err := goftp.DialConfig(config, serverConnection)
+	if err != nil {
+		return err
+	}
+
+	// Close the FTP connection afterwards
+	defer client.Close()
+
+	// Retrieve the file from the FTP server
+	err = client.Retrieve(repositoryEvent.FilePath, file)
+
+	// Make sure missing files can be recognised as such
+	ftpError := goftp.Error(nil)
+	if errors.As(err, &ftpError) && ftpError.Code() == ftpFileUnavailable {
+		return fmt.Errorf("%w: %w", err, fs.ErrNotExist)
+	}
+
+	return err
+}
+
+// Delete a path from the repository
+func deleteRepositoryPath(client *goftp.Client, deletePath string) {
+	// We're not certain if deletePath refers to a file or a directory.
+
+	// So first, we try to read it as a directory.
+	fileInfos, _ := client.ReadDir(deletePath)
+	if len(fileInfos) > 0 {
+		// If it works, we delete all contents recursively, then remove the directory itself.
+		for _, fileInfo := range fileInfos {
+			deleteRepositoryPath(client, deletePath+"/"+fileInfo.Name())
+		}
+		client.Rmdir(deletePath)
+	} else {
+		// If it fails, we assume it's a file and delete it directly.
+		client.Delete(deletePath)
+	}
+}
+
+// Delete a path from the FTP server
+func (f *tFTPRepositoryBackend) deletePath(deletePath string) error {
+	// Connect to the FTP server
+	client, err := f.ftpConnect()
+	if err != nil {
+		return err
+	}
+
+	// Close the FTP connection afterwards
+	defer client.Close()
+
+	// Then, delete the given path from the FTP server
+	deleteRepositoryPath(client, deletePath)
+
+	// Forget the created paths, as they may have been deleted
+	f.createdPathsMutex.Lock()
+	f.createdPaths = map[string]bool{}
+	f.createdPathsMutex.Unlock()
+
+	return nil
+}
+
+// The URL scheme of the files on the FTP server
+func (f *tFTPRepositoryBackend) scheme() string {
+	return ftpURLScheme
+}
+
+// Nothing to release, as each operation uses a connection of its own
+func (f *tFTPRepositoryBackend) release() {
+}
+
+/*
+ * Creating FTP repository backends
+ */
+
+// Create an FTP repository backend
+func createFTPRepositoryBackend(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error) {
+	// Create the repository backend
+	f := tFTPRepositoryBackend{}
+
+	// Get data from the config file
+	f.port = configData.GetValue("ftp", "port").String()
+	f.user = configData.GetValue("ftp", "user").String()
+	f.server = configData.GetValue("ftp", "server").String()
+	f.password = configData.GetValue("ftp", "password").String()
+	f.singleServerMode = configData.GetValue("ftp", "single_server_mode").BoolWithDefault(false)
+	f.activeTransfers = configData.GetValue("ftp", "active_transfers").BoolWithDefault(false)
+
+	// Get the FTPS configuration from the config file
+	tlsConfig, err := configData.TLSConfig("ftp")
+	if err != nil {
+		return nil, fmt.Errorf("configuring TLS for the FTP server: %w", err)
+	}
+	f.tlsConfig = tlsConfig
+
+	switch tlsMode := configData.GetValue("ftp", "tls_mode").StringWithDefault("explicit"); tlsMode {
+	case "explicit":
+		f.tlsMode = goftp.TLSExplicit
+	case "implicit":
+		f.tlsMode = goftp.TLSImplicit
+	default:
+		return nil, fmt.Errorf("unknown FTPS mode: %s", tlsMode)
+	}
+
+	// Initialising other data
+	f.reporter = reporter
+	f.createdPaths = map[string]bool{}
+
+	// Reporting on the configuration
+	if f.singleServerMode {
+		f.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in single server mode.")
+	} else {
+		f.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in multi server mode.")
+	}
+
+	// Reporting on the transfer mode
+	if f.activeTransfers {
+		f.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in active transfer mode.")
+	} else {
+		f.reporter.Progress(generics.ProgressLevelDetailed, "Running the FTP connection in passive transfer mode.")
+	}
+
+	// Reporting on the use of FTPS
+	if f.tlsConfig != nil {
+		f.reporter.Progress(generics.ProgressLevelDetailed, "Securing the FTP connection using FTPS.")
+	}
+
+	// Return the created repository backend
+	return &f, nil
+}
+
+func init() {
+	repositoryBackendCreators[ftpRepositoryBackendKind] = createFTPRepositoryBackend
+}
@@ -0,0 +1,344 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository HTTP
+ *
+ * This component provides a repository backend based on an HTTP(S) server supporting PUT requests, such as a
+ * WebDAV server. Posting a file is done using a PUT request, while retrieving it is done using a GET request.
+ * As the repository events contain the URL of the files, browser-based modelling front-ends can fetch the
+ * artefacts directly.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	httpRepositoryBackendKind = "http"  // Kind of the HTTP-based repository backend
+	httpURLScheme             = "http"  // URL scheme for files on an HTTP server
+	httpsURLScheme            = "https" // URL scheme for files on an HTTPS server
+
+	webDAVMakeCollection = "MKCOL" // WebDAV method to create a collection (directory)
+)
+
+/*
+ * Generic HTTP functionality
+ */
+
+// Determine the length of the content of a reader, reading it in memory when it is not a local file
+func readerWithLength(file io.Reader) (io.Reader, int64, error) {
+	// For local files, we can simply ask for their size
+	if localFile, isLocalFile := file.(*os.File); isLocalFile {
+		fileInfo, err := localFile.Stat()
+		if err != nil {
+			return file, 0, err
+		}
+
+		return file, fileInfo.Size(), nil
+	}
+
+	// Otherwise, we need to read the content
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return file, 0, err
+	}
+
+	return bytes.NewReader(content), int64(len(content)), nil
+}
+
+// Check the status of an HTTP response, closing the response when it is not successful
+func checkHTTPResponse(response *http.Response, acceptedStatusCodes ...int) error {
+	// All 2xx status codes are accepted
+	if response.StatusCode >= 200 && response.StatusCode <= 299 {
+		return nil
+	}
+
+	// As well as the explicitly accepted status codes
+	for _, acceptedStatusCode := range acceptedStatusCodes {
+		if response.StatusCode == acceptedStatusCode {
+			return nil
+		}
+	}
+
+	// Otherwise, we report the status
+	message, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	err := fmt.Errorf("%s %s failed with status \"%s\": %s", response.Request.Method, response.Request.URL.Redacted(), response.Status, message)
+
+	// Make sure missing files can be recognised as such
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", err, fs.ErrNotExist)
+	}
+
+	// As well as servers that are temporarily unavailable
+	if response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", err, errServerUnavailable)
+	}
+
+	return err
+}
+
+// Retrieve a file given its HTTP(S) URL
+func retrieveFileFromHTTPURL(client *http.Client, fileURL string, file io.Writer, authorise ...func(*http.Request)) error {
+	// Create the request
+	request, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return err
+	}
+
+	// Authorise the request, when needed
+	for _, authoriseRequest := range authorise {
+		authoriseRequest(request)
+	}
+
+	// Download the file
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	if err = checkHTTPResponse(response); err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Write the file
+	_, err = io.Copy(file, response.Body)
+
+	return err
+}
+
+/*
+ * Defining the HTTP repository backend
+ */
+
+type (
+	tHTTPRepositoryBackend struct {
+		endpoint, // Base URL for posting files
+		publicURL *url.URL // Base URL for retrieving files, as announced in the repository events
+
+		user, // HTTP user
+		password string // HTTP password
+
+		webDAV bool // Whether the server is a WebDAV server, requiring collections to be created
+
+		createdPathsMutex sync.Mutex      // Guards the created paths
+		createdPaths      map[string]bool // Paths already created on the WebDAV server
+
+		httpClient *http.Client // The HTTP client to be used
+
+		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
+	}
+)
+
+// Get the URL of a remote path, relative to a base URL
+func httpURLFor(baseURL *url.URL, remotePath string) *url.URL {
+	return baseURL.JoinPath(strings.Split(strings.TrimPrefix(remotePath, "/"), "/")...)
+}
+
+// Add the credentials to a request, when these have been configured
+func (h *tHTTPRepositoryBackend) authorise(request *http.Request) {
+	if h.user != "" {
+		request.SetBasicAuth(h.user, h.password)
+	}
+}
+
+// Perform an authorised request on the HTTP server
+func (h *tHTTPRepositoryBackend) do(method string, requestURL *url.URL, body io.Reader, contentLength int64, acceptedStatusCodes ...int) error {
+	// Create the request
+	request, err := http.NewRequest(method, requestURL.String(), body)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = contentLength
+	h.authorise(request)
+
+	// Perform the request
+	response, err := h.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	if err = checkHTTPResponse(response, acceptedStatusCodes...); err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Make sure the given repository file path exists as a collection on the WebDAV server
+func (h *tHTTPRepositoryBackend) mkRepositoryFilePath(remoteFilePath string) error {
+	pathCovered := ""
+	for _, directory := range strings.Split(remoteFilePath, "/") {
+		// Skip empty directory names, e.g. resulting from a leading "/"
+		if directory == "" || directory == "." {
+			continue
+		}
+		pathCovered = pathCovered + directory + "/"
+
+		// Create the collection on the WebDAV server, if not already done
+		h.createdPathsMutex.Lock()
+		created := h.createdPaths[pathCovered]
+		h.createdPathsMutex.Unlock()
+
+		if !created {
+			// Existing collections result in a "405 Method Not Allowed" (or sometimes a redirect)
+			err := h.do(webDAVMakeCollection, httpURLFor(h.endpoint, pathCovered), nil, 0,
+				http.StatusMethodNotAllowed, http.StatusMovedPermanently)
+			if err != nil {
+				return err
+			}
+
+			// Mark the path as created
+			h.createdPathsMutex.Lock()
+			h.createdPaths[pathCovered] = true
+			h.createdPathsMutex.Unlock()
+		}
+	}
+
+	return nil
+}
+
+// Store a file on the HTTP server
+func (h *tHTTPRepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	repositoryEvent := tRepositoryEvent{}
+
+	// Make sure the path exists on a WebDAV server
+	if h.webDAV {
+		if err := h.mkRepositoryFilePath(path.Dir(remoteFilePath)); err != nil {
+			return repositoryEvent, err
+		}
+	}
+
+	// Determine the length of the file
+	body, contentLength, err := readerWithLength(file)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Upload the file
+	err = h.do(http.MethodPut, httpURLFor(h.endpoint, remoteFilePath), body, contentLength)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Define the repository event
+	repositoryEvent.FilePath = remoteFilePath
+	repositoryEvent.URL = httpURLFor(h.publicURL, remoteFilePath).String()
+
+	// Return the repository event
+	return repositoryEvent, nil
+}
+
+// Retrieve a file from the HTTP server
+func (h *tHTTPRepositoryBackend) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Only provide our credentials to our own server
+	fileURL, err := url.Parse(repositoryEvent.URL)
+	if err != nil {
+		return err
+	}
+	if fileURL.Host == h.endpoint.Host || fileURL.Host == h.publicURL.Host {
+		return retrieveFileFromHTTPURL(h.httpClient, repositoryEvent.URL, file, h.authorise)
+	}
+
+	return retrieveFileFromHTTPURL(h.httpClient, repositoryEvent.URL, file)
+}
+
+// Delete a file, or a collection on a WebDAV server, from the HTTP server
+func (h *tHTTPRepositoryBackend) deletePath(remotePath string) error {
+	// Forget the created paths, as they may be deleted
+	h.createdPathsMutex.Lock()
+	h.createdPaths = map[string]bool{}
+	h.createdPathsMutex.Unlock()
+
+	// Deleting a path that does not exist is fine
+	return h.do(http.MethodDelete, httpURLFor(h.endpoint, remotePath), nil, 0, http.StatusNotFound)
+}
+
+// The URL scheme of the files on the HTTP server
+func (h *tHTTPRepositoryBackend) scheme() string {
+	return h.publicURL.Scheme
+}
+
+// Nothing to release, as the HTTP client leaves its connections to the shared transport
+func (h *tHTTPRepositoryBackend) release() {
+}
+
+/*
+ * Creating HTTP repository backends
+ */
+
+// Get the hosts of the HTTP repository in the config data, from which files may be retrieved. The HTTP repository
+// may also be shared with agents using another kind of repository backend.
+func httpRepositoryHostsFromConfig(configData *generics.TConfigData) []string {
+	hosts := []string{}
+	for _, key := range []string{"endpoint", "public_url"} {
+		if baseURL, err := url.Parse(configData.GetValue("http", key).String()); err == nil && baseURL.Host != "" {
+			hosts = append(hosts, baseURL.Host)
+		}
+	}
+
+	return hosts
+}
+
+// Create an HTTP repository backend
+func createHTTPRepositoryBackend(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error) {
+	// Create the repository backend
+	h := tHTTPRepositoryBackend{}
+
+	// Get data from the config file
+	endpoint := configData.GetValue("http", "endpoint").String()
+	publicURL := configData.GetValue("http", "public_url").StringWithDefault(endpoint)
+	h.user = configData.GetValue("http", "user").String()
+	h.password = configData.GetValue("http", "password").String()
+	h.webDAV = configData.GetValue("http", "webdav").BoolWithDefault(true)
+
+	// Check the URLs
+	var err error
+	h.endpoint, err = url.Parse(endpoint)
+	if err != nil || endpoint == "" {
+		return nil, errors.New("no valid endpoint configured for the HTTP repository")
+	}
+	h.publicURL, err = url.Parse(publicURL)
+	if err != nil {
+		return nil, fmt.Errorf("no valid public URL configured for the HTTP repository: %w", err)
+	}
+
+	// Initialising other data
+	h.createdPaths = map[string]bool{}
+	h.httpClient = &http.Client{}
+	h.reporter = reporter
+
+	// Reporting on the configuration
+	if h.webDAV {
+		h.reporter.Progress(generics.ProgressLevelDetailed, "Using WebDAV repository at: %s", h.endpoint.Redacted())
+	} else {
+		h.reporter.Progress(generics.ProgressLevelDetailed, "Using HTTP repository at: %s", h.endpoint.Redacted())
+	}
+
+	// Return the created repository backend
+	return &h, nil
+}
+
+func init() {
+	repositoryBackendCreators[httpRepositoryBackendKind] = createHTTPRepositoryBackend
+}
@@ -0,0 +1,156 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository HTTP (tests)
+ *
+ * These tests store and retrieve files by means of the HTTP repository backend, using an in-process stand-in for
+ * a WebDAV server.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// An in-process stand-in for a WebDAV server
+type tTestWebDAVServer struct {
+	mutex       sync.Mutex        // Guards the files and collections
+	files       map[string][]byte // The files, by their path
+	collections map[string]bool   // The collections, by their path
+}
+
+// Handle a request on the WebDAV server
+func (s *tTestWebDAVServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch request.Method {
+	case webDAVMakeCollection:
+		if s.collections[request.URL.Path] {
+			response.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.collections[request.URL.Path] = true
+		response.WriteHeader(http.StatusCreated)
+
+	case http.MethodPut:
+		content, _ := io.ReadAll(request.Body)
+		s.files[request.URL.Path] = content
+		response.WriteHeader(http.StatusCreated)
+
+	case http.MethodGet:
+		content, known := s.files[request.URL.Path]
+		if !known {
+			http.Error(response, "Not Found", http.StatusNotFound)
+			return
+		}
+		response.Write(content)
+
+	case http.MethodDelete:
+		for filePath := range s.files {
+			if filePath == request.URL.Path || strings.HasPrefix(filePath, strings.TrimSuffix(request.URL.Path, "/")+"/") {
+				delete(s.files, filePath)
+			}
+		}
+		for collectionPath := range s.collections {
+			if strings.HasPrefix(collectionPath, strings.TrimSuffix(request.URL.Path, "/")+"/") {
+				delete(s.collections, collectionPath)
+			}
+		}
+		response.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(response, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Start an in-process stand-in for a WebDAV server
+func startTestWebDAVServer(t *testing.T) string {
+	server := httptest.NewServer(&tTestWebDAVServer{files: map[string][]byte{}, collections: map[string]bool{}})
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestHTTPRepositoryRoundTrip(t *testing.T) {
+	endpoint := startTestWebDAVServer(t)
+	repository := createTestRepositoryConnector(t, "[repository]\nkind = http\n\n[http]\nendpoint = "+endpoint)
+
+	event, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{"celsius":21}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+	if !strings.HasPrefix(event.URL, endpoint+"/") {
+		t.Errorf("expected a URL on the server, got %s", event.URL)
+	}
+
+	file := bytes.Buffer{}
+	if err := repository.retrieveFile(event, &file); err != nil {
+		t.Fatalf("retrieving: %s", err)
+	}
+	if file.String() != `{"celsius":21}` {
+		t.Errorf("got %s", file.String())
+	}
+
+	// After deleting the environment, the file is gone
+	if err := repository.deleteEnvironment("test"); err != nil {
+		t.Fatalf("deleting: %s", err)
+	}
+	if err := repository.retrieveFile(event, &bytes.Buffer{}); err == nil {
+		t.Error("retrieved a deleted file")
+	}
+}
+
+func TestHTTPRepositoryRefusesOtherServers(t *testing.T) {
+	endpoint := startTestWebDAVServer(t)
+	otherEndpoint := startTestWebDAVServer(t)
+
+	// The other server is known to agents configured for it, whichever kind of repository they use
+	other := createTestRepositoryConnector(t, "[repository]\nkind = http\n\n[http]\nendpoint = "+otherEndpoint)
+	event, err := other.addJSONAsFile("observations/json/temperature", []byte(`{}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+
+	listener := createTestRepositoryConnector(t, "[repository]\nkind = memory\n\n[memory]\nname = "+t.Name()+"\n\n[http]\nendpoint = "+otherEndpoint)
+	if err := listener.retrieveFile(event, &bytes.Buffer{}); err != nil {
+		t.Errorf("retrieving from the configured server: %s", err)
+	}
+
+	// But not to agents configured for another one
+	repository := createTestRepositoryConnector(t, "[repository]\nkind = http\n\n[http]\nendpoint = "+endpoint)
+	if err := repository.retrieveFile(event, &bytes.Buffer{}); err == nil {
+		t.Errorf("retrieved %s from another server", event.URL)
+	}
+}
+
+func TestHTTPRepositoryConcurrentPostings(t *testing.T) {
+	repository := createTestRepositoryConnector(t, "[repository]\nkind = http\n\n[http]\nendpoint = "+startTestWebDAVServer(t))
+
+	// Several goroutines post at the same time, each creating paths on the server
+	wait := sync.WaitGroup{}
+	for _, observation := range []string{"a", "b", "c", "d"} {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+
+			if _, err := repository.addJSONAsFile("observations/json/"+observation, []byte(`{}`), "now"); err != nil {
+				t.Errorf("adding %s: %s", observation, err)
+			}
+		}()
+	}
+	wait.Wait()
+}
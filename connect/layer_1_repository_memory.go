@@ -0,0 +1,195 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository Memory
+ *
+ * This component provides an in-process repository backend.
+ * All repository backends (within one process) that use the same in-memory repository name share their files,
+ * making it possible to run several agents, for instance in tests, without an FTP server.
+ * An in-memory repository exists as long as repository backends use it. Once the last one has been released,
+ * the repository is released as well, including its files.
+ * As the name of an in-memory repository can be any string, the URLs of its files mention it as a query parameter.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	memoryRepositoryBackendKind = "memory" // Kind of the in-memory repository backend
+	memoryURLScheme             = "memory" // URL scheme for files in an in-memory repository
+)
+
+/*
+ * Defining the in-memory repository
+ */
+
+type (
+	tInMemoryRepository struct {
+		users int // The number of repository backends using the repository, guarded by inMemoryRepositoriesMutex
+
+		mutex sync.Mutex // Guards the files
+
+		files map[string][]byte // The stored files, per remote file path
+	}
+)
+
+var (
+	inMemoryRepositoriesMutex sync.Mutex                          // Guards the in-memory repositories
+	inMemoryRepositories      = map[string]*tInMemoryRepository{} // The in-memory repositories, per name
+)
+
+// Start using the in-memory repository with the given name, creating it when needed
+func acquireInMemoryRepository(name string) *tInMemoryRepository {
+	inMemoryRepositoriesMutex.Lock()
+	defer inMemoryRepositoriesMutex.Unlock()
+
+	if _, defined := inMemoryRepositories[name]; !defined {
+		inMemoryRepositories[name] = &tInMemoryRepository{
+			files: map[string][]byte{},
+		}
+	}
+	inMemoryRepositories[name].users++
+
+	return inMemoryRepositories[name]
+}
+
+// Stop using the in-memory repository with the given name, releasing it when it is no longer used
+func releaseInMemoryRepository(name string) {
+	inMemoryRepositoriesMutex.Lock()
+	defer inMemoryRepositoriesMutex.Unlock()
+
+	if repository, defined := inMemoryRepositories[name]; defined {
+		repository.users--
+		if repository.users <= 0 {
+			delete(inMemoryRepositories, name)
+		}
+	}
+}
+
+// Get the URL for a file in the in-memory repository with the given name
+func memoryURLFor(name, filePath string) string {
+	return (&url.URL{
+		Scheme:   memoryURLScheme,
+		Path:     "/" + strings.TrimPrefix(filePath, "/"),
+		RawQuery: url.Values{"name": {name}}.Encode(),
+	}).String()
+}
+
+/*
+ * Defining the in-memory repository backend
+ */
+
+type (
+	tInMemoryRepositoryBackend struct {
+		name string // The name of the in-memory repository used
+
+		repository *tInMemoryRepository // The in-memory repository used
+		released   sync.Once            // Ensures the in-memory repository is released only once
+	}
+)
+
+// Store a file in the in-memory repository
+func (m *tInMemoryRepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	repositoryEvent := tRepositoryEvent{}
+
+	// Read the file
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Store the file
+	m.repository.mutex.Lock()
+	m.repository.files[remoteFilePath] = content
+	m.repository.mutex.Unlock()
+
+	// Define the repository event
+	repositoryEvent.FilePath = remoteFilePath
+	repositoryEvent.URL = memoryURLFor(m.name, remoteFilePath)
+
+	// Return the repository event
+	return repositoryEvent, nil
+}
+
+// Retrieve a file from the in-memory repository
+func (m *tInMemoryRepositoryBackend) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Get the file
+	m.repository.mutex.Lock()
+	content, defined := m.repository.files[repositoryEvent.FilePath]
+	m.repository.mutex.Unlock()
+
+	// Check whether the file exists
+	if !defined {
+		return fmt.Errorf("file not found: %s: %w", repositoryEvent.FilePath, fs.ErrNotExist)
+	}
+
+	// Write the file
+	_, err := io.Copy(file, bytes.NewReader(content))
+
+	return err
+}
+
+// Delete a file or directory tree from the in-memory repository
+func (m *tInMemoryRepositoryBackend) deletePath(remotePath string) error {
+	m.repository.mutex.Lock()
+	defer m.repository.mutex.Unlock()
+
+	for remoteFilePath := range m.repository.files {
+		if remoteFilePath == remotePath || strings.HasPrefix(remoteFilePath, remotePath+"/") {
+			delete(m.repository.files, remoteFilePath)
+		}
+	}
+
+	return nil
+}
+
+// The URL scheme of the files in the in-memory repository
+func (m *tInMemoryRepositoryBackend) scheme() string {
+	return memoryURLScheme
+}
+
+// Stop using the in-memory repository
+func (m *tInMemoryRepositoryBackend) release() {
+	m.released.Do(func() { releaseInMemoryRepository(m.name) })
+}
+
+/*
+ * Creating in-memory repository backends
+ */
+
+// Create an in-memory repository backend
+func createInMemoryRepositoryBackend(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error) {
+	// Create the repository backend
+	m := tInMemoryRepositoryBackend{}
+
+	// Get the in-memory repository to be used
+	m.name = configData.GetValue("memory", "name").StringWithDefault(defaultInMemoryName)
+	m.repository = acquireInMemoryRepository(m.name)
+
+	// Report on the configuration
+	reporter.Progress(generics.ProgressLevelDetailed, "Using in-memory repository: %s", m.name)
+
+	// Return the created repository backend
+	return &m, nil
+}
+
+func init() {
+	repositoryBackendCreators[memoryRepositoryBackendKind] = createInMemoryRepositoryBackend
+}
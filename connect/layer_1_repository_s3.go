@@ -0,0 +1,457 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository S3
+ *
+ * This component provides a repository backend based on an S3-compatible object store (such as AWS S3 or MinIO).
+ * The topic paths are mapped to object keys in a bucket. Requests are signed using AWS Signature Version 4.
+ * Optionally, the repository events contain pre-signed URLs, so listeners can retrieve the objects without needing
+ * any credentials for the object store. As pre-signed URLs expire, while repository events may be retained on the
+ * event bus, agents using the same object store retrieve the objects using their own credentials once the
+ * pre-signed URL no longer works.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	s3RepositoryBackendKind = "s3"        // Kind of the S3-based repository backend
+	s3URLScheme             = "s3"        // URL scheme for objects in an S3-based repository
+	defaultS3Region         = "us-east-1" // Region used when no region is configured
+
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256" // The AWS Signature Version 4 signing algorithm
+	s3UnsignedPayload  = "UNSIGNED-PAYLOAD" // Payload hash used when the payload is not signed
+	s3TimeFormat       = "20060102T150405Z" // Format of AWS Signature Version 4 timestamps
+	s3DateFormat       = "20060102"         // Format of AWS Signature Version 4 dates
+)
+
+/*
+ * Defining the S3 repository backend
+ */
+
+type (
+	tS3RepositoryBackend struct {
+		endpoint, // S3 endpoint, e.g. "https://s3.eu-central-1.amazonaws.com" or "http://localhost:9000"
+		region, // S3 region
+		bucket, // S3 bucket
+		accessKey, // S3 access key
+		secretKey string // S3 secret key
+
+		pathStyle, // Whether to use path style ("endpoint/bucket/key") rather than virtual host style addressing
+		presign bool // Whether to include pre-signed URLs in the repository events
+
+		presignExpiry time.Duration // How long pre-signed URLs remain valid
+
+		httpClient *http.Client // The HTTP client to be used
+
+		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
+	}
+
+	// The result of listing the objects in a bucket
+	tS3ListBucketResult struct {
+		Contents []struct {
+			Key string `xml:"Key"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+)
+
+/*
+ * Signing requests
+ */
+
+// Encode a string according to the AWS Signature Version 4 rules, optionally keeping slashes
+func s3URIEncode(s string, keepSlashes bool) string {
+	encoded := strings.Builder{}
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && keepSlashes:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+
+	return encoded.String()
+}
+
+// Get the canonical query string of a URL
+func s3CanonicalQuery(query url.Values) string {
+	keys := []string{}
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parameters := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parameters = append(parameters, s3URIEncode(key, false)+"="+s3URIEncode(value, false))
+		}
+	}
+
+	return strings.Join(parameters, "&")
+}
+
+// Compute an HMAC-SHA256
+func s3HMAC(key []byte, data string) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(data))
+
+	return hash.Sum(nil)
+}
+
+// Compute the hex encoded SHA256 hash of some data
+func s3Hash(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// Get the credential scope for a given moment
+func (s *tS3RepositoryBackend) credentialScope(moment time.Time) string {
+	return moment.Format(s3DateFormat) + "/" + s.region + "/s3/aws4_request"
+}
+
+// Compute the signature of a request, given its canonical form
+func (s *tS3RepositoryBackend) signature(moment time.Time, canonicalRequest string) string {
+	stringToSign := s3SigningAlgorithm +
+		"\n" + moment.Format(s3TimeFormat) +
+		"\n" + s.credentialScope(moment) +
+		"\n" + s3Hash([]byte(canonicalRequest))
+
+	signingKey := s3HMAC([]byte("AWS4"+s.secretKey), moment.Format(s3DateFormat))
+	signingKey = s3HMAC(signingKey, s.region)
+	signingKey = s3HMAC(signingKey, "s3")
+	signingKey = s3HMAC(signingKey, "aws4_request")
+
+	return hex.EncodeToString(s3HMAC(signingKey, stringToSign))
+}
+
+// Sign a request by means of the authorization header
+func (s *tS3RepositoryBackend) signRequest(request *http.Request, payloadHash string) {
+	moment := time.Now().UTC()
+
+	// Set the headers that are to be signed
+	request.Header.Set("x-amz-date", moment.Format(s3TimeFormat))
+	request.Header.Set("x-amz-content-sha256", payloadHash)
+
+	// Define the canonical request
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + moment.Format(s3TimeFormat) + "\n"
+	canonicalRequest := request.Method +
+		"\n" + s3URIEncode(request.URL.Path, true) +
+		"\n" + s3CanonicalQuery(request.URL.Query()) +
+		"\n" + canonicalHeaders +
+		"\n" + signedHeaders +
+		"\n" + payloadHash
+
+	// Set the authorization header
+	request.Header.Set("Authorization", s3SigningAlgorithm+
+		" Credential="+s.accessKey+"/"+s.credentialScope(moment)+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+s.signature(moment, canonicalRequest))
+}
+
+// Get a pre-signed URL for retrieving an object
+func (s *tS3RepositoryBackend) presignedURLFor(objectKey string) string {
+	moment := time.Now().UTC()
+	objectURL := s.objectURLFor(objectKey)
+
+	// Define the query parameters that are to be signed
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3SigningAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.credentialScope(moment))
+	query.Set("X-Amz-Date", moment.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.presignExpiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	// Define the canonical request
+	canonicalRequest := http.MethodGet +
+		"\n" + s3URIEncode(objectURL.Path, true) +
+		"\n" + s3CanonicalQuery(query) +
+		"\n" + "host:" + objectURL.Host + "\n" +
+		"\n" + "host" +
+		"\n" + s3UnsignedPayload
+
+	// Add the signature
+	query.Set("X-Amz-Signature", s.signature(moment, canonicalRequest))
+	objectURL.RawQuery = s3CanonicalQuery(query)
+
+	return objectURL.String()
+}
+
+/*
+ * Object store operations
+ */
+
+// Get the object key for a given remote file path
+func s3ObjectKeyFor(remoteFilePath string) string {
+	return strings.TrimPrefix(remoteFilePath, "/")
+}
+
+// Get the URL of the bucket
+func (s *tS3RepositoryBackend) bucketURL() *url.URL {
+	bucketURL, _ := url.Parse(s.endpoint)
+	if s.pathStyle {
+		bucketURL.Path = "/" + s.bucket + "/"
+	} else {
+		bucketURL.Host = s.bucket + "." + bucketURL.Host
+		bucketURL.Path = "/"
+	}
+
+	return bucketURL
+}
+
+// Get the URL of an object
+func (s *tS3RepositoryBackend) objectURLFor(objectKey string) *url.URL {
+	objectURL := s.bucketURL()
+	objectURL.Path = objectURL.Path + objectKey
+
+	return objectURL
+}
+
+// Check whether a URL refers to an object in our own bucket
+func (s *tS3RepositoryBackend) isOwnURL(fileURL string) bool {
+	parsedURL, err := url.Parse(fileURL)
+	if err != nil {
+		return false
+	}
+	bucketURL := s.bucketURL()
+
+	return parsedURL.Host == bucketURL.Host && strings.HasPrefix(parsedURL.Path, bucketURL.Path)
+}
+
+// Perform a signed request on the object store
+func (s *tS3RepositoryBackend) do(method string, requestURL *url.URL, body io.Reader, contentLength int64, payloadHash string) (*http.Response, error) {
+	// Create the request
+	request, err := http.NewRequest(method, requestURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = contentLength
+
+	// Sign the request
+	s.signRequest(request, payloadHash)
+
+	// Perform the request
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHTTPResponse(response); err != nil {
+		return nil, err
+	}
+
+	// Return the response
+	return response, nil
+}
+
+// Store a file in the object store
+func (s *tS3RepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	repositoryEvent := tRepositoryEvent{}
+	objectKey := s3ObjectKeyFor(remoteFilePath)
+
+	// Determine the length of the file
+	body, contentLength, err := readerWithLength(file)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Upload the object
+	response, err := s.do(http.MethodPut, s.objectURLFor(objectKey), body, contentLength, s3UnsignedPayload)
+	if err != nil {
+		return repositoryEvent, err
+	}
+	response.Body.Close()
+
+	// Define the repository event
+	repositoryEvent.FilePath = remoteFilePath
+	if s.presign {
+		repositoryEvent.URL = s.presignedURLFor(objectKey)
+	} else {
+		repositoryEvent.URL = (&url.URL{Scheme: s3URLScheme, Host: s.bucket, Path: "/" + objectKey}).String()
+	}
+
+	// Return the repository event
+	return repositoryEvent, nil
+}
+
+// Retrieve a file from the object store
+func (s *tS3RepositoryBackend) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Download the object, using our own bucket and credentials
+	response, err := s.do(http.MethodGet, s.objectURLFor(s3ObjectKeyFor(repositoryEvent.FilePath)), nil, 0, s3Hash([]byte{}))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Write the file
+	_, err = io.Copy(file, response.Body)
+
+	return err
+}
+
+// List the keys of the objects underneath a given prefix
+func (s *tS3RepositoryBackend) listObjectKeys(prefix string) ([]string, error) {
+	objectKeys := []string{}
+	continuationToken := ""
+
+	for {
+		// Define the list request
+		listURL := s.bucketURL()
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		listURL.RawQuery = s3CanonicalQuery(query)
+
+		// List the objects
+		response, err := s.do(http.MethodGet, listURL, nil, 0, s3Hash([]byte{}))
+		if err != nil {
+			return objectKeys, err
+		}
+
+		// Parse the result
+		listResult := tS3ListBucketResult{}
+		err = xml.NewDecoder(response.Body).Decode(&listResult)
+		response.Body.Close()
+		if err != nil {
+			return objectKeys, err
+		}
+
+		// Collect the object keys
+		for _, content := range listResult.Contents {
+			objectKeys = append(objectKeys, content.Key)
+		}
+
+		// Continue when the result was truncated
+		if !listResult.IsTruncated {
+			return objectKeys, nil
+		}
+		continuationToken = listResult.NextContinuationToken
+	}
+}
+
+// Delete an object, or all objects underneath a path, from the object store
+func (s *tS3RepositoryBackend) deletePath(remotePath string) error {
+	objectKey := s3ObjectKeyFor(remotePath)
+
+	// Collect the objects underneath the path
+	objectKeys, err := s.listObjectKeys(objectKey + "/")
+	if err != nil {
+		return err
+	}
+
+	// The path itself may also refer to an object
+	objectKeys = append(objectKeys, objectKey)
+
+	// Delete the objects
+	for _, objectKey := range objectKeys {
+		response, err := s.do(http.MethodDelete, s.objectURLFor(objectKey), nil, 0, s3Hash([]byte{}))
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+	}
+
+	return nil
+}
+
+// The URL scheme of the objects in the object store
+func (s *tS3RepositoryBackend) scheme() string {
+	return s3URLScheme
+}
+
+// Nothing to release, as the HTTP client leaves its connections to the shared transport
+func (s *tS3RepositoryBackend) release() {
+}
+
+/*
+ * Creating S3 repository backends
+ */
+
+// Get the host of the bucket of the S3 repository in the config data, from which pre-signed URLs may be retrieved.
+// The S3 repository may also be shared with agents using another kind of repository backend.
+func s3RepositoryHostsFromConfig(configData *generics.TConfigData) []string {
+	s := tS3RepositoryBackend{}
+	s.endpoint = strings.TrimSuffix(configData.GetValue("s3", "endpoint").String(), "/")
+	s.bucket = configData.GetValue("s3", "bucket").String()
+	s.pathStyle = configData.GetValue("s3", "path_style").BoolWithDefault(true)
+
+	if endpointURL, err := url.Parse(s.endpoint); err != nil || endpointURL.Host == "" || s.bucket == "" {
+		return []string{}
+	}
+
+	return []string{s.bucketURL().Host}
+}
+
+// Create an S3 repository backend
+func createS3RepositoryBackend(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error) {
+	// Create the repository backend
+	s := tS3RepositoryBackend{}
+
+	// Get data from the config file
+	s.endpoint = strings.TrimSuffix(configData.GetValue("s3", "endpoint").String(), "/")
+	s.region = configData.GetValue("s3", "region").StringWithDefault(defaultS3Region)
+	s.bucket = configData.GetValue("s3", "bucket").String()
+	s.accessKey = configData.GetValue("s3", "access_key").String()
+	s.secretKey = configData.GetValue("s3", "secret_key").String()
+	s.pathStyle = configData.GetValue("s3", "path_style").BoolWithDefault(true)
+	s.presign = configData.GetValue("s3", "presign").BoolWithDefault(false)
+	s.presignExpiry = time.Duration(configData.GetValue("s3", "presign_expiry").IntWithDefault(3600)) * time.Second
+
+	// Check the endpoint
+	if _, err := url.Parse(s.endpoint); err != nil || s.endpoint == "" || s.bucket == "" {
+		return nil, errors.New("no valid endpoint and bucket configured for the S3 repository")
+	}
+
+	// Initialising other data
+	s.httpClient = &http.Client{}
+	s.reporter = reporter
+
+	// Reporting on the configuration
+	s.reporter.Progress(generics.ProgressLevelDetailed, "Using S3 bucket \"%s\" at: %s", s.bucket, s.endpoint)
+	if s.presign {
+		s.reporter.Progress(generics.ProgressLevelDetailed, "Announcing pre-signed URLs, valid for %s.", s.presignExpiry)
+	}
+
+	// Return the created repository backend
+	return &s, nil
+}
+
+func init() {
+	repositoryBackendCreators[s3RepositoryBackendKind] = createS3RepositoryBackend
+}
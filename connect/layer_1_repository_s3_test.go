@@ -0,0 +1,205 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository S3 (tests)
+ *
+ * These tests store and retrieve files by means of the S3 repository backend, using an in-process stand-in for an
+ * S3-compatible object store (such as MinIO) with path style addressing.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	testS3Bucket    = "modelling-bus" // Bucket used in tests
+	testS3AccessKey = "access"        // Access key used in tests
+)
+
+// An in-process stand-in for an S3-compatible object store, with a single bucket
+type tTestS3Store struct {
+	mutex   sync.Mutex        // Guards the objects
+	objects map[string][]byte // The objects, by their key
+
+	presignedURLsExpired atomic.Bool // Whether pre-signed URLs are to be treated as expired
+}
+
+// Handle a request on the object store
+func (s *tTestS3Store) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	// Only our own bucket is known
+	objectKey, inBucket := strings.CutPrefix(request.URL.Path, "/"+testS3Bucket+"/")
+	if !inBucket {
+		http.Error(response, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	// Requests must be signed, either by means of a header or a pre-signed URL
+	query := request.URL.Query()
+	switch {
+	case strings.HasPrefix(request.Header.Get("Authorization"), s3SigningAlgorithm+" Credential="+testS3AccessKey+"/"):
+	case query.Get("X-Amz-Signature") != "" && strings.HasPrefix(query.Get("X-Amz-Credential"), testS3AccessKey+"/"):
+		if s.presignedURLsExpired.Load() {
+			http.Error(response, "AccessDenied: Request has expired", http.StatusForbidden)
+			return
+		}
+	default:
+		http.Error(response, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case request.Method == http.MethodPut:
+		content, _ := io.ReadAll(request.Body)
+		s.objects[objectKey] = content
+
+	case request.Method == http.MethodGet && objectKey == "" && query.Get("list-type") == "2":
+		listResult := tS3ListBucketResult{}
+		for key := range s.objects {
+			if strings.HasPrefix(key, query.Get("prefix")) {
+				listResult.Contents = append(listResult.Contents, struct {
+					Key string `xml:"Key"`
+				}{Key: key})
+			}
+		}
+		sort.Slice(listResult.Contents, func(i, j int) bool { return listResult.Contents[i].Key < listResult.Contents[j].Key })
+		xml.NewEncoder(response).Encode(listResult)
+
+	case request.Method == http.MethodGet:
+		content, known := s.objects[objectKey]
+		if !known {
+			http.Error(response, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		response.Write(content)
+
+	case request.Method == http.MethodDelete:
+		delete(s.objects, objectKey)
+		response.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(response, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Start an in-process stand-in for an S3-compatible object store
+func startTestS3Store(t *testing.T) (*tTestS3Store, string) {
+	store := &tTestS3Store{objects: map[string][]byte{}}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+
+	return store, server.URL
+}
+
+// Get the repository config for the S3 repository backend in tests
+func testS3RepositoryConfig(endpoint string, presign bool) string {
+	presignValue := "false"
+	if presign {
+		presignValue = "true"
+	}
+
+	return "[repository]\nkind = s3\n\n" +
+		"[s3]\nendpoint = " + endpoint + "\nbucket = " + testS3Bucket + "\naccess_key = " + testS3AccessKey + "\nsecret_key = secret\npresign = " + presignValue
+}
+
+func TestS3RepositoryRoundTrip(t *testing.T) {
+	store, endpoint := startTestS3Store(t)
+	repository := createTestRepositoryConnector(t, testS3RepositoryConfig(endpoint, false))
+
+	event, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{"celsius":21}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+	if event.scheme() != s3URLScheme {
+		t.Errorf("expected an S3 URL, got %s", event.URL)
+	}
+
+	file := bytes.Buffer{}
+	if err := repository.retrieveFile(event, &file); err != nil {
+		t.Fatalf("retrieving: %s", err)
+	}
+	if file.String() != `{"celsius":21}` {
+		t.Errorf("got %s", file.String())
+	}
+
+	// After deleting the environment, the bucket is empty
+	if err := repository.deleteEnvironment("test"); err != nil {
+		t.Fatalf("deleting: %s", err)
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("objects left after deleting: %v", store.objects)
+	}
+}
+
+func TestS3RepositoryPresignedURLs(t *testing.T) {
+	store, endpoint := startTestS3Store(t)
+	repository := createTestRepositoryConnector(t, testS3RepositoryConfig(endpoint, true))
+
+	event, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{"celsius":21}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+	if !strings.HasPrefix(event.URL, endpoint+"/") || !strings.Contains(event.URL, "X-Amz-Signature=") {
+		t.Fatalf("expected a pre-signed URL, got %s", event.URL)
+	}
+
+	// Listeners without credentials for the object store can use the pre-signed URL, as long as they know the store
+	listener := createTestRepositoryConnector(t, "[repository]\nkind = memory\n\n[memory]\nname = "+t.Name()+
+		"\n\n[s3]\nendpoint = "+endpoint+"\nbucket = "+testS3Bucket)
+	file := bytes.Buffer{}
+	if err := listener.retrieveFile(event, &file); err != nil {
+		t.Fatalf("retrieving by means of the pre-signed URL: %s", err)
+	}
+	if file.String() != `{"celsius":21}` {
+		t.Errorf("got %s", file.String())
+	}
+
+	// Once the pre-signed URL has expired, agents using the same object store use their own credentials
+	store.presignedURLsExpired.Store(true)
+	if err := listener.retrieveFile(event, &bytes.Buffer{}); err == nil {
+		t.Error("retrieved by means of an expired pre-signed URL")
+	}
+
+	file.Reset()
+	if err := repository.retrieveFile(event, &file); err != nil {
+		t.Fatalf("retrieving after the pre-signed URL expired: %s", err)
+	}
+	if file.String() != `{"celsius":21}` {
+		t.Errorf("got %s", file.String())
+	}
+}
+
+func TestS3RepositoryRefusesOtherServers(t *testing.T) {
+	_, endpoint := startTestS3Store(t)
+	_, otherEndpoint := startTestS3Store(t)
+	repository := createTestRepositoryConnector(t, testS3RepositoryConfig(endpoint, true))
+	other := createTestRepositoryConnector(t, testS3RepositoryConfig(otherEndpoint, true))
+
+	event, err := other.addJSONAsFile("observations/json/temperature", []byte(`{}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+
+	if err := repository.retrieveFile(event, &bytes.Buffer{}); err == nil {
+		t.Errorf("retrieved %s from another server", event.URL)
+	}
+}
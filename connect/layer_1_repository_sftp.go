@@ -0,0 +1,366 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository SFTP
+ *
+ * This component provides the SFTP-based repository backend, using the functionality of "github.com/pkg/sftp" and
+ * "golang.org/x/crypto/ssh".
+ * Next to password-based authentication, it supports key-based authentication, as configured in the "sftp"
+ * section of the config file.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sftpRepositoryBackendKind = "sftp" // Kind of the SFTP-based repository backend
+	sftpURLScheme             = "sftp" // URL scheme for files on an SFTP server
+	defaultSFTPPort           = "22"   // Port used when no SFTP port is configured
+)
+
+/*
+ * Defining the SFTP repository backend
+ */
+
+type (
+	tSFTPRepositoryBackend struct {
+		port, // SFTP port
+		user, // SFTP user
+		server string // SFTP server
+
+		singleServerMode bool // Whether to use a single SFTP server for all agents and environments
+
+		sshConfig *ssh.ClientConfig // The SSH client configuration, including authentication
+
+		createdPathsMutex sync.Mutex      // Guards the created paths
+		createdPaths      map[string]bool // Paths already created on the SFTP server
+
+		reporter *generics.TReporter // The Reporter to be used to report progress, error, and panics
+	}
+
+	// SFTP client, bundled with the underlying SSH connection
+	tSFTPClient struct {
+		*sftp.Client
+
+		sshClient *ssh.Client // The underlying SSH connection
+	}
+)
+
+/*
+ * SFTP connection and operations
+ */
+
+// Close the SFTP client as well as the underlying SSH connection
+func (c *tSFTPClient) Close() error {
+	err := c.Client.Close()
+	c.sshClient.Close()
+
+	return err
+}
+
+// Connecting to an SFTP server
+func (s *tSFTPRepositoryBackend) sftpConnect(server, port string) (*tSFTPClient, error) {
+	// Connect to the SSH server
+	sshClient, err := ssh.Dial("tcp", server+":"+port, s.sshConfig)
+	if err != nil {
+		s.reporter.Error("Error connecting to the SFTP server. %s", err)
+		return nil, err
+	}
+
+	// Start the SFTP session
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		s.reporter.Error("Error starting the SFTP session. %s", err)
+		return nil, err
+	}
+
+	// Return the connected client
+	return &tSFTPClient{client, sshClient}, nil
+}
+
+// Make sure the given repository file path exists on the SFTP server
+func (s *tSFTPRepositoryBackend) mkRepositoryFilePath(client *tSFTPClient, remoteFilePath string) error {
+	// Create the path on the SFTP server, if not already done
+	s.createdPathsMutex.Lock()
+	created := s.createdPaths[remoteFilePath]
+	s.createdPathsMutex.Unlock()
+
+	if !created {
+		// Create all directories in the path, if not already existing
+		err := client.MkdirAll(remoteFilePath)
+		if err != nil {
+			return err
+		}
+
+		// Mark the path as created
+		s.createdPathsMutex.Lock()
+		s.createdPaths[remoteFilePath] = true
+		s.createdPathsMutex.Unlock()
+	}
+
+	return nil
+}
+
+// Store a file on the SFTP server
+func (s *tSFTPRepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	repositoryEvent := tRepositoryEvent{}
+
+	// Connect to the SFTP server
+	client, err := s.sftpConnect(s.server, s.port)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Close the SFTP connection afterwards
+	defer client.Close()
+
+	// Make sure the path exists on the SFTP server
+	err = s.mkRepositoryFilePath(client, path.Dir(remoteFilePath))
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Create the remote file
+	remoteFile, err := client.Create(remoteFilePath)
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Store the file on the SFTP server
+	_, err = remoteFile.ReadFrom(file)
+	closeErr := remoteFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return repositoryEvent, err
+	}
+
+	// Define the repository event
+	if !s.singleServerMode {
+		repositoryEvent.Server = s.server
+		repositoryEvent.Port = s.port
+	}
+	repositoryEvent.FilePath = remoteFilePath
+	repositoryEvent.URL = serverURLFor(sftpURLScheme, repositoryEvent.Server, repositoryEvent.Port, remoteFilePath)
+
+	// Return the repository event
+	return repositoryEvent, nil
+}
+
+// Retrieve a file from the SFTP server
+func (s *tSFTPRepositoryBackend) retrieveFile(repositoryEvent tRepositoryEvent, file io.Writer) error {
+	// Determine server connection details
+	server, port := s.server, s.port
+	if !s.singleServerMode {
+		server, port = repositoryEvent.Server, repositoryEvent.Port
+	}
+
+	// Connect to the SFTP server
+	client, err := s.sftpConnect(server, port)
+	if err != nil {
+		return err
+	}
+
+	// Close the SFTP connection afterwards
+	defer client.Close()
+
+	// Open the remote file
+	remoteFile, err := client.Open(repositoryEvent.FilePath)
+	if err != nil {
+		return err
+	}
+
+	// Close the remote file afterwards
+	defer remoteFile.Close()
+
+	// Retrieve the file from the SFTP server
+	_, err = remoteFile.WriteTo(file)
+
+	return err
+}
+
+// Delete a path from the SFTP server, recursively
+func deleteSFTPRepositoryPath(client *tSFTPClient, deletePath string) error {
+	// Determine whether deletePath refers to a file or a directory
+	fileInfo, err := client.Lstat(deletePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// If it is a file, we delete it directly
+	if !fileInfo.IsDir() {
+		return client.Remove(deletePath)
+	}
+
+	// Otherwise, we delete all contents recursively, then remove the directory itself
+	fileInfos, err := client.ReadDir(deletePath)
+	if err != nil {
+		return err
+	}
+	for _, fileInfo := range fileInfos {
+		err = deleteSFTPRepositoryPath(client, deletePath+"/"+fileInfo.Name())
+		if err != nil {
+			return err
+		}
+	}
+
+	return client.RemoveDirectory(deletePath)
+}
+
+// Delete a path from the SFTP server
+func (s *tSFTPRepositoryBackend) deletePath(deletePath string) error {
+	// Connect to the SFTP server
+	client, err := s.sftpConnect(s.server, s.port)
+	if err != nil {
+		return err
+	}
+
+	// Close the SFTP connection afterwards
+	defer client.Close()
+
+	// Forget the created paths, as they may be deleted
+	s.createdPathsMutex.Lock()
+	s.createdPaths = map[string]bool{}
+	s.createdPathsMutex.Unlock()
+
+	// Then, delete the given path from the SFTP server
+	return deleteSFTPRepositoryPath(client, deletePath)
+}
+
+// The URL scheme of the files on the SFTP server
+func (s *tSFTPRepositoryBackend) scheme() string {
+	return sftpURLScheme
+}
+
+// Nothing to release, as each operation uses a connection of its own
+func (s *tSFTPRepositoryBackend) release() {
+}
+
+/*
+ * Configuring SSH
+ */
+
+// Get the SSH authentication methods from the config data
+func sftpAuthMethods(configData *generics.TConfigData) ([]ssh.AuthMethod, error) {
+	authMethods := []ssh.AuthMethod{}
+
+	// Key-based authentication
+	if keyFile := configData.GetValue("sftp", "key_file").String(); keyFile != "" {
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return authMethods, err
+		}
+
+		// Parse the private key, using the passphrase if provided
+		var signer ssh.Signer
+		if passphrase := configData.GetValue("sftp", "key_passphrase").String(); passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return authMethods, err
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	// Password-based authentication
+	if password := configData.GetValue("sftp", "password").String(); password != "" {
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+
+	return authMethods, nil
+}
+
+// Get the SSH host key callback from the config data
+func sftpHostKeyCallback(configData *generics.TConfigData, reporter *generics.TReporter) (ssh.HostKeyCallback, error) {
+	// Verify host keys against a known hosts file
+	if knownHostsFile := configData.GetValue("sftp", "known_hosts").String(); knownHostsFile != "" {
+		return knownhosts.New(knownHostsFile)
+	}
+
+	// Only skip the verification of host keys when explicitly configured
+	if configData.GetValue("sftp", "insecure_ignore_host_key").BoolWithDefault(false) {
+		reporter.Progress(generics.ProgressLevelBasic, "Not verifying the host keys of SFTP servers.")
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	return nil, errors.New("neither known_hosts nor insecure_ignore_host_key has been configured")
+}
+
+/*
+ * Creating SFTP repository backends
+ */
+
+// Create an SFTP repository backend
+func createSFTPRepositoryBackend(configData *generics.TConfigData, reporter *generics.TReporter) (tRepositoryBackend, error) {
+	// Create the repository backend
+	s := tSFTPRepositoryBackend{}
+
+	// Get data from the config file
+	s.port = configData.GetValue("sftp", "port").StringWithDefault(defaultSFTPPort)
+	s.user = configData.GetValue("sftp", "user").String()
+	s.server = configData.GetValue("sftp", "server").String()
+	s.singleServerMode = configData.GetValue("sftp", "single_server_mode").BoolWithDefault(false)
+
+	// Configure the SSH authentication
+	authMethods, err := sftpAuthMethods(configData)
+	if err != nil {
+		return nil, fmt.Errorf("configuring the SFTP authentication: %w", err)
+	}
+
+	// Configure the verification of host keys
+	hostKeyCallback, err := sftpHostKeyCallback(configData, reporter)
+	if err != nil {
+		return nil, fmt.Errorf("configuring the SFTP host key verification: %w", err)
+	}
+
+	// Define the SSH client configuration
+	s.sshConfig = &ssh.ClientConfig{
+		User:            s.user,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+	}
+
+	// Initialising other data
+	s.reporter = reporter
+	s.createdPaths = map[string]bool{}
+
+	// Reporting on the configuration
+	if s.singleServerMode {
+		s.reporter.Progress(generics.ProgressLevelDetailed, "Running the SFTP connection in single server mode.")
+	} else {
+		s.reporter.Progress(generics.ProgressLevelDetailed, "Running the SFTP connection in multi server mode.")
+	}
+
+	// Return the created repository backend
+	return &s, nil
+}
+
+func init() {
+	repositoryBackendCreators[sftpRepositoryBackendKind] = createSFTPRepositoryBackend
+}
@@ -0,0 +1,205 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Repository SFTP (tests)
+ *
+ * These tests store and retrieve files by means of the SFTP repository backend, using an in-process SSH server
+ * with an in-memory SFTP file system, and key-based authentication.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Generate an SSH key pair for tests
+func generateTestSSHKey(t *testing.T) (ed25519.PrivateKey, ssh.Signer) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKey, signer
+}
+
+// Serve the SFTP subsystem on the sessions of an SSH connection
+func serveTestSFTPConnection(connection net.Conn, config *ssh.ServerConfig, handlers sftp.Handlers) {
+	_, channels, requests, err := ssh.NewServerConn(connection, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for request := range channelRequests {
+				isSFTP := request.Type == "subsystem" && string(request.Payload[4:]) == "sftp"
+				request.Reply(isSFTP, nil)
+
+				if isSFTP {
+					server := sftp.NewRequestServer(channel, handlers)
+					server.Serve()
+					server.Close()
+				}
+			}
+		}()
+	}
+}
+
+// Start an in-process SSH server, accepting the given client key, and returning its address and host key
+func startTestSFTPServer(t *testing.T, clientKey ssh.PublicKey) (string, string, ssh.PublicKey) {
+	_, hostSigner := generateTestSSHKey(t)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	// All connections share the same in-memory file system
+	handlers := sftp.InMemHandler()
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveTestSFTPConnection(connection, config, handlers)
+		}
+	}()
+
+	server, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	return server, port, hostSigner.PublicKey()
+}
+
+// Create an SFTP repository connector for tests, with key-based authentication against an in-process SSH server
+func createTestSFTPRepositoryConnector(t *testing.T) *tModellingBusRepositoryConnector {
+	clientKey, clientSigner := generateTestSSHKey(t)
+	server, port, hostKey := startTestSFTPServer(t, clientSigner.PublicKey())
+
+	// Store the client key
+	keyBlock, err := ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(keyBlock), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Store the host key
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{net.JoinHostPort(server, port)}, hostKey)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return createTestRepositoryConnector(t, "[repository]\nkind = sftp\n\n"+
+		"[sftp]\nserver = "+server+"\nport = "+port+"\nuser = agent\nkey_file = "+keyFile+"\nknown_hosts = "+knownHostsFile)
+}
+
+func TestSFTPRepositoryRoundTrip(t *testing.T) {
+	repository := createTestSFTPRepositoryConnector(t)
+
+	event, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{"celsius":21}`), "now")
+	if err != nil {
+		t.Fatalf("adding: %s", err)
+	}
+	if event.scheme() != sftpURLScheme {
+		t.Errorf("expected an SFTP URL, got %s", event.URL)
+	}
+
+	file := bytes.Buffer{}
+	if err := repository.retrieveFile(event, &file); err != nil {
+		t.Fatalf("retrieving: %s", err)
+	}
+	if file.String() != `{"celsius":21}` {
+		t.Errorf("got %s", file.String())
+	}
+
+	// After deleting the environment, the file is gone
+	if err := repository.deleteEnvironment("test"); err != nil {
+		t.Fatalf("deleting: %s", err)
+	}
+	if err := repository.retrieveFile(event, &bytes.Buffer{}); err == nil {
+		t.Error("retrieved a deleted file")
+	}
+}
+
+func TestSFTPRepositoryRejectsUnknownHostKey(t *testing.T) {
+	repository := createTestSFTPRepositoryConnector(t)
+
+	// Another server, with another host key, on the same address is not trusted
+	backend := repository.backend.(*tSFTPRepositoryBackend)
+	_, clientSigner := generateTestSSHKey(t)
+	backend.server, backend.port, _ = startTestSFTPServer(t, clientSigner.PublicKey())
+
+	if _, err := repository.addJSONAsFile("observations/json/temperature", []byte(`{}`), "now"); err == nil {
+		t.Error("connected to a server with an unknown host key")
+	}
+}
+
+func TestSFTPRepositoryConcurrentPostings(t *testing.T) {
+	repository := createTestSFTPRepositoryConnector(t)
+
+	// Several goroutines post at the same time, each creating paths on the server
+	wait := sync.WaitGroup{}
+	for _, observation := range []string{"a", "b", "c", "d"} {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+
+			if _, err := repository.addJSONAsFile("observations/json/"+observation, []byte(`{}`), "now"); err != nil {
+				t.Errorf("adding %s: %s", observation, err)
+			}
+		}()
+	}
+	wait.Wait()
+}
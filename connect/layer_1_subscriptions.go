@@ -0,0 +1,228 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Subscriptions
+ *
+ * This component provides the subscriptions as returned by the ListenFor* functions. A subscription can be
+ * ended by the agent (see Unsubscribe), and ends as well when the connector is closed. Done allows agents to wait
+ * for the end of a subscription, while Err tells why it ended.
+ * Each subscription calls its event handler from a goroutine of its own, one event at a time, in order of arrival.
+ * So, a slow event handler holds up its own subscription, but not the other subscriptions of the agent.
+ * Problems with handling individual postings, such as failing to retrieve a file from the repository, do not end
+ * a subscription. They are passed to the error handler of the subscription (see SetErrorHandler), or reported by
+ * the reporter when no error handler has been set.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"sync"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining subscriptions
+ */
+
+type (
+	Subscription struct {
+		unsubscribe func() error // Function to end the subscription on the event bus
+
+		endOnce sync.Once     // Ensures the subscription ends only once
+		done    chan struct{} // Closed when the subscription has ended
+
+		mutex        sync.Mutex  // Guards the error and the error handler
+		err          error       // The reason why the subscription ended, if not by unsubscribing
+		errorHandler func(error) // Handler for problems with handling postings
+
+		handlerQueue *tHandlerQueue // Queue for calling the event handler of the subscription
+
+		reporter *generics.TReporter // The Reporter to be used when no error handler has been set
+	}
+
+	// A queue of event handler calls, which are made one at a time, in order of arrival of the events.
+	// This way, slow event handlers do not hold up the receipt of events from the event bus, while event handlers
+	// do not need to guard against being called concurrently. Each subscription has a queue of its own.
+	tHandlerQueue struct {
+		mutex    sync.Mutex    // Guards the queued calls
+		calls    []func()      // Calls that have been queued, but have not yet been made
+		newCalls chan struct{} // Signals the arrival of new calls
+		stopped  chan struct{} // Closed when the queue has been stopped
+		stopOnce sync.Once     // Ensures the queue is stopped only once
+	}
+)
+
+/*
+ * Ending subscriptions
+ */
+
+// End the subscription, where the given error is the reason for doing so
+func (s *Subscription) end(err error) {
+	s.endOnce.Do(func() {
+		s.mutex.Lock()
+		s.err = err
+		s.mutex.Unlock()
+
+		// Stop calling the event handler
+		s.handlerQueue.stop()
+
+		close(s.done)
+	})
+}
+
+// Check whether the subscription has ended
+func (s *Subscription) hasEnded() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+ * Queueing event handler calls
+ */
+
+// Queue a call of an event handler, without waiting for it to be made
+func (q *tHandlerQueue) queue(call func()) {
+	q.mutex.Lock()
+	q.calls = append(q.calls, call)
+	q.mutex.Unlock()
+
+	// Signal the arrival, unless already signalled
+	select {
+	case q.newCalls <- struct{}{}:
+	default:
+	}
+}
+
+// Make the queued calls in order of arrival, until the queue is stopped
+func (q *tHandlerQueue) run() {
+	for {
+		// Wait for new calls
+		select {
+		case <-q.newCalls:
+		case <-q.stopped:
+			return
+		}
+
+		// Take the queued calls
+		q.mutex.Lock()
+		calls := q.calls
+		q.calls = nil
+		q.mutex.Unlock()
+
+		// Make them, as long as the queue has not been stopped
+		for _, call := range calls {
+			select {
+			case <-q.stopped:
+				return
+			default:
+				call()
+			}
+		}
+	}
+}
+
+// Stop the queue, dropping the calls that have not yet been made
+func (q *tHandlerQueue) stop() {
+	q.stopOnce.Do(func() {
+		close(q.stopped)
+	})
+}
+
+// Create a handler queue, and start making the queued calls
+func createHandlerQueue() *tHandlerQueue {
+	q := &tHandlerQueue{
+		newCalls: make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+	}
+
+	go q.run()
+
+	return q
+}
+
+/*
+ * Reporting problems
+ */
+
+// Report a problem with handling a posting
+func (s *Subscription) reportError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	errorHandler := s.errorHandler
+	s.mutex.Unlock()
+
+	if errorHandler != nil {
+		errorHandler(err)
+	} else {
+		s.reporter.Error("Something went wrong handling a posting. %s", err)
+	}
+}
+
+/*
+ * Creating subscriptions
+ */
+
+// Create a subscription, which has not yet been set up on the event bus
+func createSubscription(reporter *generics.TReporter) *Subscription {
+	return &Subscription{
+		unsubscribe:  func() error { return nil },
+		done:         make(chan struct{}),
+		handlerQueue: createHandlerQueue(),
+		reporter:     reporter,
+	}
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// End the subscription. Ending a subscription that has already ended has no effect.
+func (s *Subscription) Unsubscribe() error {
+	if s.hasEnded() {
+		return nil
+	}
+
+	err := s.unsubscribe()
+	s.end(nil)
+
+	return err
+}
+
+// Get a channel that is closed when the subscription has ended
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Get the reason why the subscription ended. This is nil while the subscription is active, as well as after
+// ending it by means of Unsubscribe. It is ErrClosed when the connector has been closed, and the error of the
+// event bus when the subscription could not be set up.
+func (s *Subscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.err
+}
+
+// Set the handler for problems with handling postings, such as failing to retrieve a file from the repository
+func (s *Subscription) SetErrorHandler(errorHandler func(error)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errorHandler = errorHandler
+}
@@ -0,0 +1,132 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Subscriptions (tests)
+ *
+ * These tests check that each subscription calls its event handler one event at a time, in order, without being
+ * held up by the other subscriptions of the agent, and that ending a subscription stops calling its handler.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+func TestSubscriptionsDoNotHoldUpEachOther(t *testing.T) {
+	poster := createTestConnector(t, "poster")
+	listener := createTestConnector(t, "listener")
+
+	// A slow handler, which waits until the end of the test
+	release := make(chan struct{})
+	defer close(release)
+	slow := listener.ListenForStreamedObservationPostings("poster", "slow", func(_ []byte, _ string) {
+		<-release
+	})
+	defer slow.Unsubscribe()
+
+	// A posting stream that is not consumed, and blocks once its buffer is full
+	blocked := listener.ListenForStreamedObservationPostingsStream("poster", "blocked", TStreamOptions{BufferSize: 1, OverflowPolicy: OverflowBlock})
+	defer blocked.Unsubscribe()
+
+	// Another subscription, which should not be held up by these
+	stream := listener.ListenForStreamedObservationPostingsStream("poster", "clicks", TStreamOptions{})
+	defer stream.Unsubscribe()
+
+	for _, observationID := range []string{"slow", "slow", "blocked", "blocked", "blocked"} {
+		if err := poster.TryPostStreamedObservation(observationID, []byte(`{}`)); err != nil {
+			t.Fatalf("posting: %s", err)
+		}
+	}
+
+	for _, click := range []string{`1`, `2`, `3`} {
+		if err := poster.TryPostStreamedObservation("clicks", []byte(click)); err != nil {
+			t.Fatalf("posting: %s", err)
+		}
+
+		if posting := receiveTestPosting(t, stream); string(posting.JSON) != click {
+			t.Errorf("expected %s, got %s", click, posting.JSON)
+		}
+	}
+}
+
+func TestUnsubscribeStopsDelivery(t *testing.T) {
+	poster := createTestConnector(t, "poster")
+	listener := createTestConnector(t, "listener")
+
+	handled := make(chan string, 10)
+	subscription := listener.ListenForStreamedObservationPostings("poster", "clicks", func(json []byte, _ string) {
+		handled <- string(json)
+	})
+
+	// Another subscription, showing when the postings have been delivered
+	stream := listener.ListenForStreamedObservationPostingsStream("poster", "clicks", TStreamOptions{})
+	defer stream.Unsubscribe()
+
+	if err := poster.TryPostStreamedObservation("clicks", []byte(`1`)); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+	receiveTestPosting(t, stream)
+	select {
+	case click := <-handled:
+		if click != `1` {
+			t.Errorf("expected 1, got %s", click)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("the posting was not handled in time")
+	}
+
+	// Ending the subscription, which can be done more than once
+	for range 2 {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Fatalf("unsubscribing: %s", err)
+		}
+	}
+	select {
+	case <-subscription.Done():
+	default:
+		t.Error("the subscription has not ended")
+	}
+	if err := subscription.Err(); err != nil {
+		t.Errorf("unexpected reason for ending the subscription: %s", err)
+	}
+
+	// Later postings no longer reach the handler
+	if err := poster.TryPostStreamedObservation("clicks", []byte(`2`)); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+	receiveTestPosting(t, stream)
+	select {
+	case click := <-handled:
+		t.Errorf("posting %s was handled after unsubscribing", click)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSyncTopicRootsAreUnique(t *testing.T) {
+	roots := map[string]bool{}
+	for range 100 {
+		root, err := syncTopicRootFor("prefix", "agent")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(root, "prefix/sync/"+generics.ModellingBusVersion+"/agent/") {
+			t.Errorf("unexpected sync topic root %s", root)
+		}
+		if roots[root] {
+			t.Errorf("sync topic root %s used twice", root)
+		}
+		roots[root] = true
+	}
+}
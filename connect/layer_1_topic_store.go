@@ -0,0 +1,137 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 1 - Topic Store
+ *
+ * This component provides a synchronised store of the (latest) messages per topic, as used by the events
+ * connector to keep track of the messages on the event bus. Messages are stored from the goroutines of the
+ * event transport, while they are read from the goroutines of the agent, so all access is guarded.
+ * Reading is possible per topic, by means of a snapshot, or by iterating over a snapshot.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"iter"
+	"maps"
+	"sync"
+)
+
+/*
+ * Defining topic stores
+ */
+
+type (
+	tTopicStore struct {
+		mutex    sync.RWMutex      // Guards the messages
+		messages map[string][]byte // The messages per topic
+	}
+)
+
+/*
+ * Reading from topic stores
+ */
+
+// Get the message for a given topic, and whether the topic is known at all
+func (s *tTopicStore) lookup(topic string) ([]byte, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	message, defined := s.messages[topic]
+
+	return message, defined
+}
+
+// Get the message for a given topic, which is empty when the topic is not known
+func (s *tTopicStore) message(topic string) []byte {
+	message, _ := s.lookup(topic)
+
+	return message
+}
+
+// Get the number of known topics
+func (s *tTopicStore) size() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.messages)
+}
+
+// Get a snapshot of all messages, which can be used without further synchronisation
+func (s *tTopicStore) snapshot() map[string][]byte {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return maps.Clone(s.messages)
+}
+
+// Iterate over a snapshot of the topics and their messages, so the store may be changed while iterating
+func (s *tTopicStore) all() iter.Seq2[string, []byte] {
+	return maps.All(s.snapshot())
+}
+
+// Iterate over a snapshot of the topics matching a topic filter, and their messages
+func (s *tTopicStore) matching(topicFilter string) iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		for topic, message := range s.all() {
+			if topicMatchesFilter(topicFilter, topic) && !yield(topic, message) {
+				return
+			}
+		}
+	}
+}
+
+/*
+ * Changing topic stores
+ */
+
+// Store the message for a given topic
+func (s *tTopicStore) store(topic string, message []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages[topic] = message
+}
+
+// Store the message for a given topic, unless the topic is already known
+func (s *tTopicStore) storeIfAbsent(topic string, message []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, defined := s.messages[topic]; !defined {
+		s.messages[topic] = message
+	}
+}
+
+// Forget a given topic
+func (s *tTopicStore) forget(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.messages, topic)
+}
+
+// Forget all topics
+func (s *tTopicStore) clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	clear(s.messages)
+}
+
+/*
+ * Creating topic stores
+ */
+
+// Create an empty topic store
+func createTopicStore() *tTopicStore {
+	return &tTopicStore{
+		messages: map[string][]byte{},
+	}
+}
@@ -12,15 +12,19 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 12.12.2025
+ * Version of: 16.10.2026
  *
  */
 
 package connect
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
@@ -33,14 +37,27 @@ type (
 		modellingBusRepositoryConnector *tModellingBusRepositoryConnector // The repository connector
 		modellingBusEventsConnector     *tModellingBusEventsConnector     // The events connector
 
+		outbox *tOutbox // The outbox for postings that could not (yet) be delivered
+
+		keepHistory bool // Whether to keep the full version history of artefacts in the repository
+
 		agentID, // The Agent ID to be used in postings on the BIG Modelling Bus
 		environmentID string // The Modelling environment ID
 
+		lifetime   context.Context    // The context bounding the lifetime of the connector, which is done once closed
+		markClosed context.CancelFunc // Function to mark the connector as closed
+		ctx        context.Context    // The context bounding the operations of the connector (see WithContext)
+
 		Reporter   *generics.TReporter   // The Reporter to be used to report progress, error, and panics
 		configData *generics.TConfigData // The configuration data to be used
 	}
 )
 
+const (
+	drainRetryInterval = time.Second      // Interval for retrying the delivery of pending postings while closing
+	drainTimeout       = 30 * time.Second // Time for delivering pending postings while closing, unless the context has a deadline
+)
+
 /*
  * Defining streamed events
  */
@@ -53,42 +70,59 @@ type (
 )
 
 /*
- * Posting things
+ * Defining archived postings
  */
 
-// Posting a file to the repository and announcing it on the event bus
-func (b *TModellingBusConnector) postFile(topicPath, localFilePath, timestamp string) {
-	// First, add the file to the repository
-	event := b.modellingBusRepositoryConnector.addFile(topicPath, localFilePath, timestamp)
+type (
+	// The event listing an archived posting, i.e. a file in the repository that is not announced as a posting itself
+	tArchivedEvent struct {
+		File    tRepositoryEvent `json:"file"`    // Location of the archived posting in the repository
+		Details json.RawMessage  `json:"details"` // Details on the archived posting
+	}
+)
+
+/*
+ * Delivering postings
+ */
 
-	// Then convert the event to JSON
+// Announce a file in the repository on the event bus
+func (b *TModellingBusConnector) announceFile(topicPath string, event tRepositoryEvent) error {
+	// First convert the event to JSON
 	message, err := json.Marshal(event)
 	if err != nil {
-		b.Reporter.Error("Something went wrong JSONing the link data. %s", err)
-		return
+		return fmt.Errorf("JSONing the link data: %w", err)
 	}
 
-	// Finally, post the event on the event bus
-	b.modellingBusEventsConnector.postEvent(topicPath, message)
+	// Then, post the event on the event bus
+	return b.modellingBusEventsConnector.postEvent(topicPath, message)
 }
 
-// Posting a JSON message as a file to the repository and announcing it on the event bus
-func (b *TModellingBusConnector) postJSONAsFile(topicPath string, jsonMessage []byte, timestamp string) {
-	// First, add the JSON as a file to the repository
-	event := b.modellingBusRepositoryConnector.addJSONAsFile(topicPath, jsonMessage, timestamp)
+// Delivering a file to the repository and announcing it on the event bus
+func (b *TModellingBusConnector) deliverFile(topicPath, localFilePath, timestamp string) error {
+	// First, add the file to the repository
+	event, err := b.modellingBusRepositoryConnector.addFile(topicPath, localFilePath, timestamp)
+	if err != nil {
+		return err
+	}
 
-	// Then convert the event to JSON
-	message, err := json.Marshal(event)
+	// Finally, announce the file on the event bus
+	return b.announceFile(topicPath, event)
+}
+
+// Delivering a JSON message as a file to the repository and announcing it on the event bus
+func (b *TModellingBusConnector) deliverJSONAsFile(topicPath string, jsonMessage []byte, timestamp string) error {
+	// First, add the JSON as a file to the repository
+	event, err := b.modellingBusRepositoryConnector.addJSONAsFile(topicPath, jsonMessage, timestamp)
 	if err != nil {
-		b.Reporter.Error("Something went wrong JSONing the link data. %s", err)
-		return
+		return err
 	}
 
-	// Finally, post the event on the event bus
-	b.modellingBusEventsConnector.postEvent(topicPath, message)
+	// Finally, announce the file on the event bus
+	return b.announceFile(topicPath, event)
 }
 
-func (b *TModellingBusConnector) postJSONAsStreamed(topicPath string, jsonMessage []byte, timestamp string) {
+// Delivering a JSON message as a streamed event on the event bus
+func (b *TModellingBusConnector) deliverJSONAsStreamed(topicPath string, jsonMessage []byte, timestamp string) error {
 	// Create the streamed event
 	event := tStreamedEvent{}
 	event.Timestamp = timestamp
@@ -97,12 +131,176 @@ func (b *TModellingBusConnector) postJSONAsStreamed(topicPath string, jsonMessag
 	// Convert the event to JSON
 	message, err := json.Marshal(event)
 	if err != nil {
-		b.Reporter.Error("Something went wrong JSONing the event. %s", err)
-		return
+		return fmt.Errorf("JSONing the event: %w", err)
 	}
 
 	// Finally, post the event on the event bus
-	b.modellingBusEventsConnector.postEvent(topicPath, message)
+	return b.modellingBusEventsConnector.postEvent(topicPath, message)
+}
+
+// Delivering a JSON message as a file archived in the repository, under a given name for the topic path, and
+// listing it on the event bus, together with the given details, underneath the topic path
+func (b *TModellingBusConnector) deliverArchivedJSON(topicPath, archiveName string, jsonMessage, details []byte, timestamp string) error {
+	// First, archive the JSON as a file in the repository
+	file, err := b.modellingBusRepositoryConnector.addJSONAsFileNamed(topicPath, archiveName+".json", jsonMessage, timestamp)
+	if err != nil {
+		return err
+	}
+
+	// Then convert the listing to JSON
+	message, err := json.Marshal(tArchivedEvent{File: file, Details: details})
+	if err != nil {
+		return fmt.Errorf("JSONing the archived posting: %w", err)
+	}
+
+	// Finally, list the archived file on the event bus
+	return b.modellingBusEventsConnector.postEvent(topicPath+"/"+archiveName, message)
+}
+
+// Deliver the postings from the outbox, in order, as long as this succeeds
+func (b *TModellingBusConnector) flushOutbox() {
+	// Only one flush at a time, to maintain the order of the postings
+	b.outbox.flushMutex.Lock()
+	defer b.outbox.flushMutex.Unlock()
+
+	// Once closed, the remaining postings wait for the next run of the agent
+	if b.lifetime.Err() != nil {
+		return
+	}
+
+	for _, entry := range b.outbox.entries() {
+		// Deliver the posting, using its original timestamp
+		err := error(nil)
+		switch entry.Kind {
+		case outboxFilePosting:
+			err = b.deliverFile(entry.TopicPath, b.outbox.payloadFilePathFor(entry), entry.Timestamp)
+
+		case outboxStreamedPosting:
+			payload, readErr := os.ReadFile(b.outbox.payloadFilePathFor(entry))
+			if readErr == nil {
+				err = b.deliverJSONAsStreamed(entry.TopicPath, payload, entry.Timestamp)
+			} else {
+				b.Reporter.Error("Dropping unreadable posting from the outbox. %s", readErr)
+			}
+
+		case outboxArchivedPosting:
+			payload, readErr := os.ReadFile(b.outbox.payloadFilePathFor(entry))
+			if readErr == nil {
+				err = b.deliverArchivedJSON(entry.TopicPath, entry.ArchiveName, payload, entry.Details, entry.Timestamp)
+			} else {
+				b.Reporter.Error("Dropping unreadable posting from the outbox. %s", readErr)
+			}
+
+		default:
+			b.Reporter.Error("Dropping posting of unknown kind \"%s\" from the outbox.", entry.Kind)
+		}
+
+		// When delivery fails for good, there is no use in trying again
+		if err != nil && !isConnectivityError(err) {
+			b.Reporter.Error("Dropping posting from the outbox that cannot be delivered. %s", err)
+			err = nil
+		}
+
+		// When delivery fails for lack of connectivity, we try again later
+		if err != nil {
+			b.Reporter.Progress(generics.ProgressLevelDetailed, "Postings in the outbox cannot be delivered yet. %s", err)
+			b.outbox.scheduleRetry(b.flushOutbox)
+			return
+		}
+
+		// The posting has been dealt with, so it can leave the outbox
+		b.outbox.remove(entry)
+		b.Reporter.Progress(generics.ProgressLevelDetailed, "Delivered posting from the outbox: %s", entry.TopicPath)
+	}
+}
+
+// Post, using the given delivery function, and put the posting in the outbox (using the given enqueue function)
+// when the repository or event bus cannot be reached. Once a posting is in the outbox, it will be delivered
+// eventually, so this does not count as a failure. Other failures, such as the repository refusing the posting,
+// are returned.
+func (b *TModellingBusConnector) postViaOutbox(deliver func() error, enqueue func(*tOutbox) error) error {
+	// Check whether we may still post
+	if err := b.contextError(); err != nil {
+		return err
+	}
+
+	// Without an outbox, we can only return failures
+	if b.outbox == nil {
+		return deliver()
+	}
+
+	// Postings are delivered in order, so the postings already in the outbox need to go first
+	if b.outbox.isPending() {
+		b.flushOutbox()
+	}
+
+	// Try to deliver the posting, unless earlier postings are still pending
+	if !b.outbox.isPending() {
+		err := deliver()
+		if err == nil || !isConnectivityError(err) {
+			return err
+		}
+
+		b.Reporter.Progress(generics.ProgressLevelBasic, "Could not deliver the posting, keeping it in the outbox. %s", err)
+	}
+
+	// Keep the posting in the outbox, and retry later
+	if err := enqueue(b.outbox); err != nil {
+		return fmt.Errorf("adding the posting to the outbox: %w", err)
+	}
+	b.outbox.scheduleRetry(b.flushOutbox)
+
+	return nil
+}
+
+/*
+ * Posting things
+ */
+
+// Posting a file to the repository and announcing it on the event bus
+func (b *TModellingBusConnector) postFile(topicPath, localFilePath, timestamp string) error {
+	return b.postViaOutbox(
+		func() error { return b.deliverFile(topicPath, localFilePath, timestamp) },
+		func(o *tOutbox) error { return o.enqueueFile(outboxFilePosting, topicPath, timestamp, localFilePath) })
+}
+
+// Posting a JSON message as a file to the repository and announcing it on the event bus
+func (b *TModellingBusConnector) postJSONAsFile(topicPath string, jsonMessage []byte, timestamp string) error {
+	return b.postViaOutbox(
+		func() error { return b.deliverJSONAsFile(topicPath, jsonMessage, timestamp) },
+		func(o *tOutbox) error { return o.enqueueJSON(outboxFilePosting, topicPath, timestamp, jsonMessage) })
+}
+
+// Posting a JSON message as a streamed event on the event bus
+func (b *TModellingBusConnector) postJSONAsStreamed(topicPath string, jsonMessage []byte, timestamp string) error {
+	return b.postViaOutbox(
+		func() error { return b.deliverJSONAsStreamed(topicPath, jsonMessage, timestamp) },
+		func(o *tOutbox) error { return o.enqueueJSON(outboxStreamedPosting, topicPath, timestamp, jsonMessage) })
+}
+
+// Archiving a JSON message as a file in the repository, under a given name for the topic path, and listing it
+// on the event bus, together with the given details, underneath the topic path. As each archived posting is
+// listed on a topic path of its own, archiving does not require an index to be maintained.
+func (b *TModellingBusConnector) postArchivedJSON(topicPath, archiveName string, jsonMessage, details []byte, timestamp string) error {
+	return b.postViaOutbox(
+		func() error { return b.deliverArchivedJSON(topicPath, archiveName, jsonMessage, details, timestamp) },
+		func(o *tOutbox) error {
+			return o.enqueueArchivedJSON(topicPath, archiveName, timestamp, jsonMessage, details)
+		})
+}
+
+/*
+ * Bounding operations
+ */
+
+// Check whether operations can (still) be performed, given the lifetime of the connector and the context
+// bounding its operations
+func (b *TModellingBusConnector) contextError() error {
+	if b.lifetime.Err() != nil {
+		return ErrClosed
+	}
+
+	return b.ctx.Err()
 }
 
 /*
@@ -110,117 +308,259 @@ func (b *TModellingBusConnector) postJSONAsStreamed(topicPath string, jsonMessag
  */
 
 // Get a linked file from the repository, given the message from the event bus
-func (b *TModellingBusConnector) getLinkedFileFromRepository(message []byte, localFileName string) (string, string) {
+func (b *TModellingBusConnector) getLinkedFileFromRepository(message []byte, localFileName string) (string, string, error) {
+	// Without a message, there is no file to retrieve
+	if len(message) == 0 {
+		return "", "", fmt.Errorf("no posting on the event bus: %w", ErrNotFound)
+	}
+
 	// Unmarshal the message to get the repository event
 	event := tRepositoryEvent{}
-
-	// Unmarshal the message
 	err := json.Unmarshal(message, &event)
-	if err == nil {
-		// Retrieve the file from the repository
-		return b.modellingBusRepositoryConnector.getFile(event, localFileName), event.Timestamp
-	} else {
-		// Something went wrong, so return an empty result
-		return "", ""
+	if err != nil {
+		return "", "", fmt.Errorf("unJSONing the link data: %w", err)
+	}
+
+	// Retrieve the file from the repository
+	localFilePath, err := b.modellingBusRepositoryConnector.getFile(event, localFileName)
+	if err != nil {
+		return "", "", err
 	}
+
+	return localFilePath, event.Timestamp, nil
 }
 
 // Get a linked file from a posting on the event bus
-func (b *TModellingBusConnector) getFileFromPosting(agentID, topicPath, localFileName string) (string, string) {
-	// Get the message from the event bus, and retrieve the file from the repository
-	return b.getLinkedFileFromRepository(b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath), localFileName)
+func (b *TModellingBusConnector) getFileFromPosting(agentID, topicPath, localFileName string) (string, string, error) {
+	// Check whether we may still retrieve
+	if err := b.contextError(); err != nil {
+		return "", "", err
+	}
+
+	// Get the message from the event bus
+	message, err := b.modellingBusEventsConnector.messageFromEvent(b.ctx, agentID, topicPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Retrieve the file from the repository
+	return b.getLinkedFileFromRepository(message, localFileName)
 }
 
 // Get JSON from a temporary file
-func (b *TModellingBusConnector) getJSONFromTemporaryFile(tempFilePath, timestamp string) ([]byte, string) {
+func (b *TModellingBusConnector) getJSONFromTemporaryFile(tempFilePath, timestamp string) ([]byte, string, error) {
 	// Read the JSON payload from the temporary file
 	jsonPayload, err := os.ReadFile(tempFilePath)
 	os.Remove(tempFilePath)
 
 	// Handle potential errors
 	if err != nil {
-		b.Reporter.Error("Something went wrong while retrieving file. %s", err)
-		b.Reporter.Error("Temporary file to be opened: %s", tempFilePath)
-		return []byte{}, ""
+		return []byte{}, "", fmt.Errorf("reading retrieved file %s: %w", tempFilePath, err)
 	}
 
 	// Return the JSON payload and timestamp
-	return jsonPayload, timestamp
+	return jsonPayload, timestamp, nil
 }
 
 // Get JSON from the repository, given a posting on the event bus
-func (b *TModellingBusConnector) getJSON(agentID, topicPath string) ([]byte, string) {
+func (b *TModellingBusConnector) getJSON(agentID, topicPath string) ([]byte, string, error) {
 	// Get the linked file from the repository
-	tempFilePath, timestamp := b.getLinkedFileFromRepository(b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath), generics.JSONFileName)
+	tempFilePath, timestamp, err := b.getFileFromPosting(agentID, topicPath, temporaryJSONFileName())
+	if err != nil {
+		return []byte{}, "", err
+	}
 
 	// Read the JSON payload from the temporary file
-	jsonPayload, err := os.ReadFile(tempFilePath)
-	os.Remove(tempFilePath)
+	return b.getJSONFromTemporaryFile(tempFilePath, timestamp)
+}
 
-	// Handle potential errors
+// Get archived JSON from the repository, given the repository event resulting from archiving it
+func (b *TModellingBusConnector) getArchivedJSON(event tRepositoryEvent) ([]byte, error) {
+	// Check whether we may still retrieve
+	if err := b.contextError(); err != nil {
+		return []byte{}, err
+	}
+
+	// Retrieve the file from the repository
+	tempFilePath, err := b.modellingBusRepositoryConnector.getFile(event, temporaryJSONFileName())
 	if err != nil {
-		return []byte{}, ""
+		return []byte{}, err
 	}
 
-	// Return the JSON payload and timestamp
-	return jsonPayload, timestamp
+	// Read the JSON payload from the temporary file
+	jsonPayload, _, err := b.getJSONFromTemporaryFile(tempFilePath, event.Timestamp)
+
+	return jsonPayload, err
+}
+
+// Get the archived postings listed underneath a given topic path, by the name under which they were archived
+func (b *TModellingBusConnector) getArchivedPostings(agentID, topicPath string) (map[string]tArchivedEvent, error) {
+	// Check whether we may still retrieve
+	if err := b.contextError(); err != nil {
+		return map[string]tArchivedEvent{}, err
+	}
+
+	// Get the listings from the event bus
+	messages, err := b.modellingBusEventsConnector.messagesFromEventsBelow(b.ctx, agentID, topicPath)
+	if err != nil {
+		return map[string]tArchivedEvent{}, err
+	}
+
+	// Unmarshal the listings
+	archivedPostings := map[string]tArchivedEvent{}
+	for archiveName, message := range messages {
+		archivedPosting := tArchivedEvent{}
+		if err := json.Unmarshal(message, &archivedPosting); err != nil {
+			return map[string]tArchivedEvent{}, fmt.Errorf("unJSONing the archived posting %s: %w", archiveName, err)
+		}
+
+		archivedPostings[archiveName] = archivedPosting
+	}
+
+	return archivedPostings, nil
 }
 
-func (b *TModellingBusConnector) getStreamed(agentID, topicPath string) ([]byte, string) {
+func (b *TModellingBusConnector) getStreamed(agentID, topicPath string) ([]byte, string, error) {
+	// Check whether we may still retrieve
+	if err := b.contextError(); err != nil {
+		return []byte{}, "", err
+	}
+
 	// Get the message from the event bus
 	event := tStreamedEvent{}
-	message := b.modellingBusEventsConnector.messageFromEvent(agentID, topicPath)
+	message, err := b.modellingBusEventsConnector.messageFromEvent(b.ctx, agentID, topicPath)
+	if err != nil {
+		return []byte{}, "", err
+	}
+	if len(message) == 0 {
+		return []byte{}, "", fmt.Errorf("no posting on the event bus: %w", ErrNotFound)
+	}
 
 	// Unmarshal the message
-	err := json.Unmarshal(message, &event)
-	if err == nil {
-		// Return the payload and timestamp
-		return event.Payload, event.Timestamp
-	} else {
-		// Something went wrong, so return an empty result
-		return []byte{}, ""
+	err = json.Unmarshal(message, &event)
+	if err != nil {
+		return []byte{}, "", fmt.Errorf("unJSONing the streamed event: %w", err)
 	}
+
+	// Return the payload and timestamp
+	return event.Payload, event.Timestamp, nil
 }
 
 /*
  * Listening for postings
  */
 
-func (b *TModellingBusConnector) listenForFilePostings(agentID, topicPath, localFileName string, postingHandler func(string, string)) {
+// The posting handlers may return an error, which is then reported by means of the subscription.
+// When a posting cannot be retrieved, this is reported as well, and the posting handler is not called.
+
+func (b *TModellingBusConnector) listenForFilePostings(agentID, topicPath, localFileName string, postingHandler func(string, string) error) *Subscription {
 	// Listen for raw file related events on the event bus
-	b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(message []byte) {
-		postingHandler(b.getLinkedFileFromRepository(message, localFileName))
+	return b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(_ string, message []byte) error {
+		localFilePath, timestamp, err := b.getLinkedFileFromRepository(message, localFileName)
+		if err != nil {
+			return fmt.Errorf("retrieving the posted file: %w", err)
+		}
+
+		return postingHandler(localFilePath, timestamp)
 	})
 }
 
-func (b *TModellingBusConnector) listenForJSONFilePostings(agentID, topicPath string, postingHandler func([]byte, string)) {
+func (b *TModellingBusConnector) listenForJSONFilePostings(agentID, topicPath string, postingHandler func([]byte, string) error) *Subscription {
 	// Listen for JSON file related events on the event bus
-	b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(message []byte) {
-		postingHandler(b.getJSONFromTemporaryFile(b.getLinkedFileFromRepository(message, generics.JSONFileName)))
+	return b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(_ string, message []byte) error {
+		tempFilePath, timestamp, err := b.getLinkedFileFromRepository(message, temporaryJSONFileName())
+		if err != nil {
+			return fmt.Errorf("retrieving the posted JSON: %w", err)
+		}
+
+		jsonPayload, timestamp, err := b.getJSONFromTemporaryFile(tempFilePath, timestamp)
+		if err != nil {
+			return fmt.Errorf("retrieving the posted JSON: %w", err)
+		}
+
+		return postingHandler(jsonPayload, timestamp)
 	})
 }
 
-func (b *TModellingBusConnector) listenForStreamedPostings(agentID, topicPath string, postingHandler func([]byte, string)) {
-	// Listen for streamed events on the event bus
-	b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, func(message []byte) {
+// Handle streamed events by calling a posting handler with the ID of the posting agent, their payload, and timestamp
+func streamedEventHandler(postingHandler func(string, []byte, string) error) func(string, []byte) error {
+	return func(agentID string, message []byte) error {
 		// Unmarshal the streamed event
 		event := tStreamedEvent{}
 		err := json.Unmarshal(message, &event)
-		if err == nil {
-			// Call the posting handler with the payload and timestamp, of the unmashalling went well.
-			postingHandler(event.Payload, event.Timestamp)
+		if err != nil {
+			return fmt.Errorf("unJSONing the streamed event: %w", err)
 		}
-	})
+
+		// Call the posting handler with the payload and timestamp, if the unmarshalling went well.
+		return postingHandler(agentID, event.Payload, event.Timestamp)
+	}
+}
+
+func (b *TModellingBusConnector) listenForStreamedPostings(agentID, topicPath string, postingHandler func([]byte, string) error) *Subscription {
+	// Listen for streamed events on the event bus
+	return b.modellingBusEventsConnector.listenForEvents(agentID, topicPath, streamedEventHandler(func(_ string, json []byte, timestamp string) error {
+		return postingHandler(json, timestamp)
+	}))
+}
+
+// Listen for streamed postings, including the ones that were already pending when connecting to the event bus.
+// As this is meant for listening to all agents, the posting handler is also given the ID of the posting agent.
+func (b *TModellingBusConnector) listenForPendingStreamedPostings(agentID, topicPath string, postingHandler func(string, []byte, string) error) *Subscription {
+	// Listen for streamed events on the event bus
+	return b.modellingBusEventsConnector.listenForPendingEvents(agentID, topicPath, streamedEventHandler(postingHandler))
 }
 
 /*
  * Deleting postings
  */
 
-func (b *TModellingBusConnector) deletePosting(topicPath string) {
+func (b *TModellingBusConnector) deletePosting(topicPath string) error {
+	// Check whether we may still delete
+	if err := b.contextError(); err != nil {
+		return err
+	}
+
 	// Delete the posting both from the event bus and the repository
-	b.modellingBusEventsConnector.deletePostingPath(topicPath)
-	b.modellingBusRepositoryConnector.deletePostingPath(topicPath)
+	return errors.Join(
+		b.modellingBusEventsConnector.deletePostingPath(topicPath),
+		b.modellingBusRepositoryConnector.deletePostingPath(topicPath))
+}
+
+// Delete the postings archived underneath a given topic path
+func (b *TModellingBusConnector) deleteArchivedPostings(topicPath string) error {
+	// Check whether we may still delete
+	if err := b.contextError(); err != nil {
+		return err
+	}
+
+	// Delete the listings from the event bus, and the archived files from the repository
+	return errors.Join(
+		b.modellingBusEventsConnector.deletePostingPathsBelow(b.ctx, topicPath),
+		b.modellingBusRepositoryConnector.deletePostingPath(topicPath))
+}
+
+// Delete a streamed posting of another agent, e.g. when consuming a request posted by that agent
+func (b *TModellingBusConnector) deleteStreamedPostingOf(agentID, topicPath string) error {
+	// Check whether we may still delete
+	if err := b.contextError(); err != nil {
+		return err
+	}
+
+	// Streamed postings only reside on the event bus
+	return b.modellingBusEventsConnector.deleteAgentPostingPath(agentID, topicPath)
+}
+
+/*
+ * Reporting errors
+ */
+
+// Report an error, if any, for the variants of the externally visible functionality that do not return errors.
+// As it is normal for postings to be absent, ErrNotFound is not reported.
+func (b *TModellingBusConnector) reportError(message string, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		b.Reporter.Error("%s %s", message, err)
+	}
 }
 
 /*
@@ -229,45 +569,181 @@ func (b *TModellingBusConnector) deletePosting(topicPath string) {
  *
  */
 
-func (b *TModellingBusConnector) DeleteEnvironment(environment ...string) {
+// Delete an environment, by default the environment of the connector, returning potential errors
+func (b *TModellingBusConnector) TryDeleteEnvironment(environment ...string) error {
 	// Determine the environment to delete
 	environmentToDelete := b.environmentID
 	if len(environment) > 0 {
 		environmentToDelete = environment[0]
 	}
 
+	// Check whether we may still delete
+	if err := b.contextError(); err != nil {
+		return err
+	}
+
 	// Report on the deletion
 	b.Reporter.Progress(1, "Deleting environment: %s", environmentToDelete)
 
 	// Delete the environment both from the event bus and the repository
-	b.modellingBusEventsConnector.deleteEnvironment(environmentToDelete)
-	b.modellingBusRepositoryConnector.deleteEnvironment(environmentToDelete)
+	return errors.Join(
+		b.modellingBusEventsConnector.deleteEnvironment(b.ctx, environmentToDelete),
+		b.modellingBusRepositoryConnector.deleteEnvironment(environmentToDelete))
 }
 
-func CreateModellingBusConnector(configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) TModellingBusConnector {
+// Delete an environment, by default the environment of the connector
+func (b *TModellingBusConnector) DeleteEnvironment(environment ...string) {
+	b.reportError("Something went wrong deleting the environment.", b.TryDeleteEnvironment(environment...))
+}
+
+// Set the handler to be called when the connection to the event bus is lost, and when it has been restored.
+// While the connection is lost, the connector keeps trying to reconnect. After reconnecting, all listeners
+// are in place again.
+func (b *TModellingBusConnector) SetConnectionStateHandler(connectionStateHandler func(connected bool)) {
+	b.modellingBusEventsConnector.setConnectionStateHandler(connectionStateHandler)
+}
+
+// Get a copy of the connector, of which the operations are bounded by the given context.
+// Artefact connectors can use such a copy as well, by setting their ModellingBusConnector.
+func (b *TModellingBusConnector) WithContext(ctx context.Context) TModellingBusConnector {
+	modellingBusConnector := *b
+	modellingBusConnector.ctx = ctx
+
+	return modellingBusConnector
+}
+
+// Deliver the pending postings from the outbox, until all have been delivered or the given context is done.
+// Without a deadline, delivering is bounded by drainTimeout. Without a connection to the event bus, the pending
+// postings cannot be delivered, so these are only tried once.
+func (b *TModellingBusConnector) drainOutbox(ctx context.Context) error {
+	if _, bounded := ctx.Deadline(); !bounded {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, drainTimeout)
+		defer cancel()
+	}
+
+	for {
+		b.flushOutbox()
+		if !b.outbox.isPending() {
+			return nil
+		}
+
+		// There is no use in waiting for the event bus to return
+		if !b.modellingBusEventsConnector.connected.Load() {
+			return fmt.Errorf("undelivered postings remain in the outbox: %w", errEventBusDisconnected)
+		}
+
+		select {
+		case <-time.After(drainRetryInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("undelivered postings remain in the outbox: %w", ctx.Err())
+		}
+	}
+}
+
+// Close the connector. Pending postings are delivered first, for as long as the given context allows, and for
+// at most 30 seconds when the context has no deadline. When not connected to the event bus, these are not
+// waited for. Postings that could not be delivered remain in the outbox for the next run of the agent. Then, all
+// subscriptions are ended, the connection to the event bus is closed, and the files retrieved from the
+// repository are removed from the work folder. Afterwards, all operations fail with ErrClosed.
+func (b *TModellingBusConnector) Close(ctx context.Context) error {
+	// Closing more than once has no further effect
+	if b.lifetime.Err() != nil {
+		return nil
+	}
+
+	b.Reporter.Progress(generics.ProgressLevelBasic, "Closing the connection to the modelling bus.")
+
+	// Deliver the pending postings
+	errs := []error{}
+	if b.outbox != nil {
+		errs = append(errs, b.drainOutbox(ctx))
+		b.outbox.stopRetrying()
+		b.outbox.unlock()
+	}
+
+	// Mark the connector as closed
+	b.markClosed()
+
+	// Disconnect from the event bus
+	errs = append(errs, b.modellingBusEventsConnector.close(ctx))
+
+	// Release the repository
+	b.modellingBusRepositoryConnector.close()
+
+	return errors.Join(errs...)
+}
+
+// Create a modelling bus connector, where the given context bounds connecting to the event bus.
+// The lifetime of the connector is not bound by the context, but ends by calling Close.
+// When the config data is not valid, e.g. selecting an unknown kind of event transport, ErrInvalidConfig is returned.
+func CreateModellingBusConnectorContext(ctx context.Context, configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) (TModellingBusConnector, error) {
 	// Create the modelling bus connector
 	modellingBusConnector := TModellingBusConnector{}
 	modellingBusConnector.environmentID = configData.GetValue("", "environment").String()
 	modellingBusConnector.agentID = configData.GetValue("", "agent").String()
 	modellingBusConnector.configData = configData
 	modellingBusConnector.Reporter = reporter
+	modellingBusConnector.lifetime, modellingBusConnector.markClosed = context.WithCancel(context.Background())
+	modellingBusConnector.ctx = context.Background()
+	modellingBusConnector.keepHistory = configData.GetValue("repository", "history").BoolWithDefault(false)
 
 	// Create the repository connector
-	modellingBusConnector.modellingBusRepositoryConnector =
+	repositoryConnector, err :=
 		createModellingBusRepositoryConnector(
 			modellingBusConnector.environmentID,
 			modellingBusConnector.agentID,
 			modellingBusConnector.configData,
 			modellingBusConnector.Reporter)
+	if err != nil {
+		modellingBusConnector.markClosed()
+		return modellingBusConnector, err
+	}
+	modellingBusConnector.modellingBusRepositoryConnector = repositoryConnector
 
 	// Create the events connector
-	modellingBusConnector.modellingBusEventsConnector =
+	eventsConnector, err :=
 		createModellingBusEventsConnector(
+			ctx,
 			modellingBusConnector.environmentID,
 			modellingBusConnector.agentID,
 			modellingBusConnector.configData,
 			modellingBusConnector.Reporter,
 			postingOnly)
+	if err != nil {
+		modellingBusConnector.markClosed()
+		modellingBusConnector.modellingBusRepositoryConnector.close()
+		return modellingBusConnector, err
+	}
+	modellingBusConnector.modellingBusEventsConnector = eventsConnector
+
+	// Create the outbox, unless disabled
+	if configData.GetValue("outbox", "enabled").BoolWithDefault(true) {
+		modellingBusConnector.outbox = createOutbox(
+			configData.GetValue("", "work_folder").String(),
+			modellingBusConnector.environmentID,
+			modellingBusConnector.agentID,
+			time.Duration(configData.GetValue("outbox", "retry_interval").IntWithDefault(defaultOutboxRetry))*time.Second,
+			modellingBusConnector.Reporter)
+	}
+
+	// Deliver the pending postings from the outbox, now, and whenever the connection to the event bus is restored
+	if modellingBusConnector.outbox != nil {
+		modellingBusConnector.modellingBusEventsConnector.setReconnectedHandler(modellingBusConnector.flushOutbox)
+		modellingBusConnector.flushOutbox()
+	}
+
+	// Return the created modelling bus connector
+	return modellingBusConnector, nil
+}
+
+// Create a modelling bus connector
+func CreateModellingBusConnector(configData *generics.TConfigData, reporter *generics.TReporter, postingOnly bool) TModellingBusConnector {
+	// Without a deadline, connecting only ends when it succeeds
+	modellingBusConnector, err := CreateModellingBusConnectorContext(context.Background(), configData, reporter, postingOnly)
+	if err != nil {
+		reporter.Panic("Could not create the modelling bus connector. %s", err)
+	}
 
 	// Return the created modelling bus connector
 	return modellingBusConnector
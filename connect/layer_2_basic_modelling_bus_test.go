@@ -0,0 +1,49 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 2 - Basic Modelling Bus (tests)
+ *
+ * These tests create modelling bus connectors from config data.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+func TestCreatingConnectorsFromInvalidConfig(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+	}{
+		{"unknown event transport", "[events]\nkind = unknown\n\n[repository]\nkind = memory"},
+		{"unknown repository backend", "[events]\nkind = memory\n\n[repository]\nkind = unknown"},
+		{"missing MQTT CA bundle", "[events]\nkind = mqtt\n\n[mqtt]\ntls = true\nca_file = " + filepath.Join(t.TempDir(), "missing.pem") + "\n\n[repository]\nkind = memory"},
+		{"unknown FTPS mode", "[events]\nkind = memory\n\n[repository]\nkind = ftp\n\n[ftp]\ntls_mode = unknown"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configData, err := generics.TryLoadConfigFromData([]byte(fmt.Sprintf("environment = test\nagent = agent\nwork_folder = %s\n\n%s\n\n[memory]\nname = %s\n", t.TempDir(), test.config, t.Name())))
+			if err != nil {
+				t.Fatalf("loading the config data: %s", err)
+			}
+
+			// Is reported as an error, rather than a panic
+			if _, err := CreateModellingBusConnectorContext(context.Background(), configData, createTestReporter(t), false); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("expected an invalid configuration, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 2 - Outbox
+ *
+ * This component provides a persistent outbox for postings that could not be delivered, because the repository
+ * or the event bus was not reachable. The outbox is kept in the "outbox" folder of the work folder, with a
+ * separate folder per modelling environment and agent, so it survives restarts of the agent. Postings in the
+ * outbox are delivered in order, using their original timestamps, once connectivity returns.
+ * With the outbox enabled (the default), posting is therefore fire-and-forget. The posting functions only return
+ * an error when a posting is refused, e.g. when the repository denies access. Agents that need to know whether
+ * their postings have been delivered can disable the outbox, using the "enabled" key of the "outbox" section.
+ * Postings in the outbox that turn out to be refused are dropped, and reported as an error.
+ * As connectors sharing a work folder must not deliver each other's postings, the outbox folder is locked by
+ * means of a lock file, naming the process using it. When the outbox folder is already in use, the connector
+ * runs without an outbox.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	outboxFolderName      = "outbox"      // Name of the outbox folder within the work folder
+	outboxEntrySuffix     = ".entry.json" // Suffix of the files describing the postings in the outbox
+	outboxPayloadSuffix   = ".payload"    // Suffix of the files containing the payloads of the postings
+	outboxLockFileName    = "outbox.lock" // Name of the lock file within the outbox folder
+	outboxFilePosting     = "file"        // Kind of posting: a file in the repository, announced on the event bus
+	outboxStreamedPosting = "streamed"    // Kind of posting: a streamed event on the event bus
+	outboxArchivedPosting = "archived"    // Kind of posting: a file archived in the repository, listed on the event bus
+	defaultOutboxRetry    = 30            // Interval (in seconds) for retrying the delivery of postings
+)
+
+/*
+ * Defining the outbox
+ */
+
+type (
+	tOutboxEntry struct {
+		Kind      string `json:"kind"`       // The kind of posting
+		TopicPath string `json:"topic path"` // The topic path of the posting
+		Timestamp string `json:"timestamp"`  // The original timestamp of the posting
+
+		ArchiveName string          `json:"archive name,omitempty"` // The name under which an archived posting is listed
+		Details     json.RawMessage `json:"details,omitempty"`      // The details listed with an archived posting
+
+		name string `json:"-"` // The name of the entry within the outbox folder
+	}
+
+	tOutbox struct {
+		mutex      sync.Mutex // Guards the outbox folder
+		flushMutex sync.Mutex // Ensures only one flush of the outbox takes place at a time
+
+		folder string // The outbox folder
+
+		lastSequence int // The last sequence number used for an entry
+
+		retryInterval time.Duration // Interval for retrying the delivery of postings
+		retryTimer    *time.Timer   // Timer for the next retry, if scheduled
+	}
+)
+
+// Get the path of a file in the outbox folder
+func (o *tOutbox) filePathFor(name, suffix string) string {
+	return filepath.Join(o.folder, name+suffix)
+}
+
+// Get the path of the payload of an entry
+func (o *tOutbox) payloadFilePathFor(entry tOutboxEntry) string {
+	return o.filePathFor(entry.name, outboxPayloadSuffix)
+}
+
+// Get the names of the entries in the outbox, in order
+func (o *tOutbox) entryNames() []string {
+	names := []string{}
+
+	dirEntries, _ := os.ReadDir(o.folder)
+	for _, dirEntry := range dirEntries {
+		if name, isEntry := strings.CutSuffix(dirEntry.Name(), outboxEntrySuffix); isEntry {
+			names = append(names, name)
+		}
+	}
+
+	// As the names are zero padded sequence numbers, sorting them yields the order of posting
+	sort.Strings(names)
+
+	return names
+}
+
+// Check whether the outbox has pending postings
+func (o *tOutbox) isPending() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return len(o.entryNames()) > 0
+}
+
+// Get the pending postings, in order
+func (o *tOutbox) entries() []tOutboxEntry {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	entries := []tOutboxEntry{}
+	for _, name := range o.entryNames() {
+		entry := tOutboxEntry{}
+		entryJSON, err := os.ReadFile(o.filePathFor(name, outboxEntrySuffix))
+		if err == nil && json.Unmarshal(entryJSON, &entry) == nil {
+			entry.name = name
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Add a posting to the outbox, with the payload being provided by a reader
+func (o *tOutbox) enqueue(entry tOutboxEntry, payload io.Reader) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	// Determine the name of the entry
+	o.lastSequence++
+	entry.name = fmt.Sprintf("%012d", o.lastSequence)
+
+	// Store the payload first
+	payloadFile, err := os.Create(o.payloadFilePathFor(entry))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(payloadFile, payload)
+	closeErr := payloadFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Then store the entry itself, which makes the posting pending
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(o.filePathFor(entry.name, outboxEntrySuffix), entryJSON, 0644)
+}
+
+// Add a posting of a local file to the outbox
+func (o *tOutbox) enqueueFile(kind, topicPath, timestamp, localFilePath string) error {
+	// Open the local file for reading
+	file, err := os.Open(filepath.FromSlash(localFilePath))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return o.enqueue(tOutboxEntry{Kind: kind, TopicPath: topicPath, Timestamp: timestamp}, file)
+}
+
+// Add a posting of a JSON message to the outbox
+func (o *tOutbox) enqueueJSON(kind, topicPath, timestamp string, jsonMessage []byte) error {
+	return o.enqueue(tOutboxEntry{Kind: kind, TopicPath: topicPath, Timestamp: timestamp}, strings.NewReader(string(jsonMessage)))
+}
+
+// Add an archived posting of a JSON message to the outbox
+func (o *tOutbox) enqueueArchivedJSON(topicPath, archiveName, timestamp string, jsonMessage, details []byte) error {
+	entry := tOutboxEntry{Kind: outboxArchivedPosting, TopicPath: topicPath, Timestamp: timestamp, ArchiveName: archiveName, Details: details}
+
+	return o.enqueue(entry, strings.NewReader(string(jsonMessage)))
+}
+
+// Remove a delivered posting from the outbox
+func (o *tOutbox) remove(entry tOutboxEntry) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	os.Remove(o.filePathFor(entry.name, outboxEntrySuffix))
+	os.Remove(o.payloadFilePathFor(entry))
+}
+
+// Schedule a retry of the delivery of the pending postings, unless one has already been scheduled
+func (o *tOutbox) scheduleRetry(flush func()) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.retryTimer == nil {
+		o.retryTimer = time.AfterFunc(o.retryInterval, func() {
+			o.mutex.Lock()
+			o.retryTimer = nil
+			o.mutex.Unlock()
+
+			flush()
+		})
+	}
+}
+
+// Stop retrying the delivery of the pending postings
+func (o *tOutbox) stopRetrying() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.retryTimer != nil {
+		o.retryTimer.Stop()
+		o.retryTimer = nil
+	}
+}
+
+// Check whether the process with the given ID is still running
+func processIsRunning(processID int) bool {
+	process, err := os.FindProcess(processID)
+	if err != nil {
+		return false
+	}
+
+	// Signal 0 only checks for the existence of the process
+	return !errors.Is(process.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
+
+// Lock the outbox folder, taking over lock files left behind by processes that are no longer running
+func (o *tOutbox) lock() error {
+	lockFilePath := filepath.Join(o.folder, outboxLockFileName)
+
+	for {
+		// Create the lock file, unless it already exists
+		lockFile, err := os.OpenFile(lockFilePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(lockFile, "%d", os.Getpid())
+
+			return errors.Join(err, lockFile.Close())
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+
+		// The lock is held as long as the process that created the lock file is running
+		processID := 0
+		lockFileContent, err := os.ReadFile(lockFilePath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if _, err := fmt.Sscanf(string(lockFileContent), "%d", &processID); err == nil && processIsRunning(processID) {
+			return fmt.Errorf("the outbox folder %s is in use by process %d", o.folder, processID)
+		}
+
+		// Otherwise, the lock file was left behind
+		if err := os.Remove(lockFilePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+}
+
+// Unlock the outbox folder
+func (o *tOutbox) unlock() {
+	os.Remove(filepath.Join(o.folder, outboxLockFileName))
+}
+
+// Create an outbox in the given work folder, for the given modelling environment and agent
+func createOutbox(workFolder, environmentID, agentID string, retryInterval time.Duration, reporter *generics.TReporter) *tOutbox {
+	// Create the outbox
+	o := tOutbox{}
+	o.folder = filepath.Join(filepath.FromSlash(workFolder), outboxFolderName, environmentID, agentID)
+	o.retryInterval = retryInterval
+
+	// Make sure the outbox folder exists
+	err := os.MkdirAll(o.folder, 0755)
+	if err != nil {
+		reporter.Error("Could not create the outbox folder. %s", err)
+		return nil
+	}
+
+	// Make sure no other connector uses the outbox folder
+	err = o.lock()
+	if err != nil {
+		reporter.Error("Could not lock the outbox folder, so running without an outbox. %s", err)
+		return nil
+	}
+
+	// Continue the sequence numbers of the postings left behind by a previous run
+	for _, name := range o.entryNames() {
+		sequence := 0
+		fmt.Sscanf(name, "%d", &sequence)
+		o.lastSequence = max(o.lastSequence, sequence)
+	}
+
+	if o.lastSequence > 0 {
+		reporter.Progress(generics.ProgressLevelBasic, "Found undelivered postings in the outbox.")
+	}
+
+	// Return the created outbox
+	return &o
+}
@@ -0,0 +1,225 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 2 - Outbox (tests)
+ *
+ * These tests keep postings in the outbox while the event bus or repository cannot be reached, and deliver them
+ * once connectivity returns.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A repository backend for tests, which fails to store files while an error has been set
+type tTestFailingRepositoryBackend struct {
+	tRepositoryBackend // The repository backend doing the actual work
+
+	mutex sync.Mutex // Guards the error
+	err   error      // The error to fail with, if any
+}
+
+// Set the error to fail with, where nil makes storing files succeed again
+func (f *tTestFailingRepositoryBackend) failWith(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.err = err
+}
+
+// Store a file, unless an error has been set
+func (f *tTestFailingRepositoryBackend) storeFile(remoteFilePath string, file io.Reader) (tRepositoryEvent, error) {
+	f.mutex.Lock()
+	err := f.err
+	f.mutex.Unlock()
+
+	if err != nil {
+		return tRepositoryEvent{}, err
+	}
+
+	return f.tRepositoryBackend.storeFile(remoteFilePath, file)
+}
+
+// Let the repository backend of a connector fail while an error has been set
+func failingTestRepositoryBackend(connector TModellingBusConnector) *tTestFailingRepositoryBackend {
+	backend := &tTestFailingRepositoryBackend{tRepositoryBackend: connector.modellingBusRepositoryConnector.backend}
+	connector.modellingBusRepositoryConnector.backend = backend
+
+	return backend
+}
+
+func TestOutboxReplaysPostingsAfterReconnecting(t *testing.T) {
+	poster := createTestConnector(t, "poster", "[outbox]\nretry_interval = 3600")
+	listener := createTestConnector(t, "listener")
+
+	stream := listener.ListenForStreamedObservationPostingsStream("poster", "clicks", TStreamOptions{})
+	defer stream.Unsubscribe()
+
+	// While disconnected, postings are kept in the outbox
+	poster.modellingBusEventsConnector.connected.Store(false)
+	for _, click := range []string{`1`, `2`, `3`} {
+		if err := poster.TryPostStreamedObservation("clicks", []byte(click)); err != nil {
+			t.Fatalf("posting while disconnected: %s", err)
+		}
+	}
+	if err := poster.TryPostJSONObservation("temperature", []byte(`{"celsius":21}`)); err != nil {
+		t.Fatalf("posting while disconnected: %s", err)
+	}
+	if !poster.outbox.isPending() {
+		t.Fatal("no postings in the outbox")
+	}
+
+	// Once reconnected, they are delivered in order
+	poster.modellingBusEventsConnector.connected.Store(true)
+	poster.flushOutbox()
+
+	for _, click := range []string{`1`, `2`, `3`} {
+		if posting := receiveTestPosting(t, stream); string(posting.JSON) != click {
+			t.Errorf("expected %s, got %s", click, posting.JSON)
+		}
+	}
+
+	json, _, err := listener.TryGetJSONObservation("poster", "temperature")
+	if err != nil {
+		t.Fatalf("getting: %s", err)
+	}
+	if string(json) != `{"celsius":21}` {
+		t.Errorf("got %s", json)
+	}
+	if poster.outbox.isPending() {
+		t.Error("postings left in the outbox")
+	}
+}
+
+func TestOutboxKeepsPostingsWhileRepositoryIsUnreachable(t *testing.T) {
+	poster := createTestConnector(t, "poster", "[outbox]\nretry_interval = 3600")
+	backend := failingTestRepositoryBackend(poster)
+
+	backend.failWith(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	if err := poster.TryPostJSONObservation("temperature", []byte(`{"celsius":21}`)); err != nil {
+		t.Fatalf("posting while unreachable: %s", err)
+	}
+	if !poster.outbox.isPending() {
+		t.Fatal("no posting in the outbox")
+	}
+
+	backend.failWith(nil)
+	poster.flushOutbox()
+
+	json, _, err := poster.TryGetJSONObservation("poster", "temperature")
+	if err != nil {
+		t.Fatalf("getting: %s", err)
+	}
+	if string(json) != `{"celsius":21}` {
+		t.Errorf("got %s", json)
+	}
+}
+
+func TestOutboxReturnsRefusedPostings(t *testing.T) {
+	poster := createTestConnector(t, "poster")
+	backend := failingTestRepositoryBackend(poster)
+
+	backend.failWith(fs.ErrPermission)
+	if err := poster.TryPostJSONObservation("temperature", []byte(`{}`)); !errors.Is(err, ErrRepositoryUnavailable) {
+		t.Errorf("expected ErrRepositoryUnavailable, got %v", err)
+	}
+	if poster.outbox.isPending() {
+		t.Error("refused posting kept in the outbox")
+	}
+}
+
+func TestOutboxIsLockedPerEnvironmentAndAgent(t *testing.T) {
+	workFolder := t.TempDir()
+	reporter := createTestReporter(t)
+
+	outbox := createOutbox(workFolder, "test", "agent", time.Hour, reporter)
+	if outbox == nil {
+		t.Fatal("creating the outbox")
+	}
+
+	// Other agents, and other environments, have their own outbox
+	for _, other := range [][2]string{{"test", "other"}, {"other", "agent"}} {
+		otherOutbox := createOutbox(workFolder, other[0], other[1], time.Hour, reporter)
+		if otherOutbox == nil {
+			t.Fatalf("creating the outbox for %v", other)
+		}
+		if otherOutbox.folder == outbox.folder {
+			t.Errorf("sharing the outbox folder with %v", other)
+		}
+		otherOutbox.unlock()
+	}
+
+	// While the same agent cannot use it twice
+	if createOutbox(workFolder, "test", "agent", time.Hour, reporter) != nil {
+		t.Error("created a locked outbox")
+	}
+
+	outbox.unlock()
+	if outbox = createOutbox(workFolder, "test", "agent", time.Hour, reporter); outbox == nil {
+		t.Error("creating the outbox after unlocking")
+	} else {
+		outbox.unlock()
+	}
+}
+
+func TestOutboxTakesOverLeftBehindLocks(t *testing.T) {
+	workFolder := t.TempDir()
+	reporter := createTestReporter(t)
+
+	// A lock file left behind by a process that is no longer running
+	outbox := createOutbox(workFolder, "test", "agent", time.Hour, reporter)
+	if outbox == nil {
+		t.Fatal("creating the outbox")
+	}
+	if err := os.WriteFile(filepath.Join(outbox.folder, outboxLockFileName), []byte("999999999"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if outbox = createOutbox(workFolder, "test", "agent", time.Hour, reporter); outbox == nil {
+		t.Error("creating the outbox with a left behind lock file")
+	} else {
+		outbox.unlock()
+	}
+}
+
+func TestOutboxDoesNotDelayClosingWhileDisconnected(t *testing.T) {
+	poster := createTestConnector(t, "poster", "[outbox]\nretry_interval = 3600")
+
+	poster.modellingBusEventsConnector.connected.Store(false)
+	if err := poster.TryPostJSONObservation("temperature", []byte(`{"celsius":21}`)); err != nil {
+		t.Fatalf("posting while disconnected: %s", err)
+	}
+
+	// Closing without a deadline returns, leaving the posting in the outbox
+	closed := make(chan error, 1)
+	go func() { closed <- poster.Close(context.Background()) }()
+
+	select {
+	case err := <-closed:
+		if !errors.Is(err, ErrBrokerUnavailable) {
+			t.Errorf("expected the event bus to be unavailable, got %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("closing did not return in time")
+	}
+	if !poster.outbox.isPending() {
+		t.Error("the posting did not remain in the outbox")
+	}
+}
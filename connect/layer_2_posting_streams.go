@@ -0,0 +1,186 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 2 - Posting Streams
+ *
+ * This component provides the delivery of postings by means of buffered Go channels, as an alternative to the
+ * callback based listening. As the postings are consumed in the goroutines of the agent, slow consumers do not
+ * stall the event bus. The channel can also be consumed as an iter.Seq stream (see All).
+ * When the buffer is full, the overflow policy determines what happens:
+ * - OverflowBlock:          wait until the consumer has made room (which does hold up the subscription);
+ * - OverflowDropOldest:     drop the oldest buffered posting to make room;
+ * - OverflowCoalesceLatest: drop all buffered postings, as only the latest one matters (e.g. for states).
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"iter"
+	"sync"
+)
+
+/*
+ * Defining posting streams
+ */
+
+type (
+	// What to do when the buffer of a posting stream is full
+	TOverflowPolicy int
+
+	// Options for posting streams
+	TStreamOptions struct {
+		BufferSize     int             // The number of postings that can be buffered, where 0 means the default size
+		OverflowPolicy TOverflowPolicy // What to do when the buffer is full
+	}
+
+	// A posting of a file, which has been retrieved into the work folder
+	TFilePosting struct {
+		LocalFilePath string // The local file path of the retrieved file
+		Timestamp     string // The timestamp of the posting
+	}
+
+	// A posting of JSON
+	TJSONPosting struct {
+		JSON      json.RawMessage // The posted JSON
+		Timestamp string          // The timestamp of the posting
+	}
+
+	// A stream of postings, which ends (and closes its channel) when the subscription ends
+	TPostingStream[T any] struct {
+		*Subscription // The subscription underlying the stream
+
+		C <-chan T // The channel delivering the postings
+
+		postings       chan T          // The channel delivering the postings, as used for sending
+		overflowPolicy TOverflowPolicy // What to do when the buffer is full
+
+		mutex   sync.Mutex    // Ensures postings are added one at a time
+		stopped chan struct{} // Closed when no more postings are to be added
+		closed  bool          // Whether the channel has been closed
+	}
+)
+
+const (
+	OverflowBlock          TOverflowPolicy = iota // Wait until the consumer has made room
+	OverflowDropOldest                            // Drop the oldest buffered posting
+	OverflowCoalesceLatest                        // Drop all buffered postings, keeping only the latest
+
+	defaultStreamBufferSize = 64 // Buffer size of posting streams, when none is provided
+)
+
+/*
+ * Delivering postings
+ */
+
+// Add a posting to the stream, taking the overflow policy into account
+func (s *TPostingStream[T]) deliver(posting T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Once closed, postings can no longer be added
+	if s.closed {
+		return
+	}
+
+	for {
+		// Try to add the posting
+		select {
+		case s.postings <- posting:
+			return
+		default:
+		}
+
+		// The buffer is full, so apply the overflow policy
+		switch s.overflowPolicy {
+		case OverflowDropOldest:
+			// Make room by dropping the oldest posting
+			select {
+			case <-s.postings:
+			default:
+			}
+
+		case OverflowCoalesceLatest:
+			// Make room by dropping all buffered postings
+			for dropping := true; dropping; {
+				select {
+				case <-s.postings:
+				default:
+					dropping = false
+				}
+			}
+
+		default:
+			// Wait until the consumer has made room, or the stream stops
+			select {
+			case s.postings <- posting:
+			case <-s.stopped:
+			}
+			return
+		}
+	}
+}
+
+// Attach the subscription underlying the stream, and close the channel once the subscription ends
+func (s *TPostingStream[T]) attach(subscription *Subscription) *TPostingStream[T] {
+	s.Subscription = subscription
+
+	go func() {
+		<-subscription.Done()
+
+		// Release a blocked delivery, if any, and then close the channel
+		close(s.stopped)
+
+		s.mutex.Lock()
+		s.closed = true
+		close(s.postings)
+		s.mutex.Unlock()
+	}()
+
+	return s
+}
+
+/*
+ * Creating posting streams
+ */
+
+// Create a posting stream, which still needs to be attached to a subscription
+func createPostingStream[T any](options TStreamOptions) *TPostingStream[T] {
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultStreamBufferSize
+	}
+
+	s := TPostingStream[T]{}
+	s.postings = make(chan T, bufferSize)
+	s.C = s.postings
+	s.overflowPolicy = options.OverflowPolicy
+	s.stopped = make(chan struct{})
+
+	return &s
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Iterate over the postings of the stream, until the subscription ends. Ending the iteration early ends the
+// subscription as well.
+func (s *TPostingStream[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for posting := range s.C {
+			if !yield(posting) {
+				s.Unsubscribe()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 2 - Posting Streams (tests)
+ *
+ * These tests check the overflow policies of posting streams, and that their channels close when the subscription
+ * ends.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// Create a posting stream of numbers, attached to a subscription that ends when the test ends
+func createTestPostingStream(t *testing.T, options TStreamOptions) *TPostingStream[int] {
+	stream := createPostingStream[int](options).attach(createSubscription(createTestReporter(t)))
+	t.Cleanup(func() { stream.Unsubscribe() })
+
+	return stream
+}
+
+// Collect the postings buffered in a stream
+func bufferedTestPostings(stream *TPostingStream[int]) []int {
+	postings := []int{}
+	for len(stream.C) > 0 {
+		postings = append(postings, <-stream.C)
+	}
+
+	return postings
+}
+
+func TestPostingStreamsDropPostingsWhenFull(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		policy   TOverflowPolicy
+		expected []int
+	}{
+		{"drop oldest", OverflowDropOldest, []int{3, 4, 5}},
+		{"coalesce latest", OverflowCoalesceLatest, []int{4, 5}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stream := createTestPostingStream(t, TStreamOptions{BufferSize: 3, OverflowPolicy: test.policy})
+
+			// Delivering more postings than fit the buffer, which never blocks
+			for posting := 1; posting <= 5; posting++ {
+				stream.deliver(posting)
+			}
+
+			if postings := bufferedTestPostings(stream); !slices.Equal(postings, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, postings)
+			}
+		})
+	}
+}
+
+func TestPostingStreamsBlockWhenFull(t *testing.T) {
+	stream := createTestPostingStream(t, TStreamOptions{BufferSize: 1, OverflowPolicy: OverflowBlock})
+
+	stream.deliver(1)
+
+	// Delivering to a full buffer waits until the consumer has made room
+	delivered := make(chan struct{})
+	go func() {
+		stream.deliver(2)
+		close(delivered)
+	}()
+
+	select {
+	case <-delivered:
+		t.Fatal("delivering to a full buffer did not wait")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if posting := receiveTestPosting(t, stream); posting != 1 {
+		t.Errorf("expected 1, got %d", posting)
+	}
+	select {
+	case <-delivered:
+	case <-time.After(testTimeout):
+		t.Fatal("delivering did not continue once the consumer made room")
+	}
+	if posting := receiveTestPosting(t, stream); posting != 2 {
+		t.Errorf("expected 2, got %d", posting)
+	}
+}
+
+func TestPostingStreamsCloseWhenUnsubscribed(t *testing.T) {
+	stream := createTestPostingStream(t, TStreamOptions{BufferSize: 1, OverflowPolicy: OverflowBlock})
+
+	// A delivery waiting for room in the buffer
+	stream.deliver(1)
+	delivered := make(chan struct{})
+	go func() {
+		stream.deliver(2)
+		close(delivered)
+	}()
+
+	// Is released when the subscription ends, after which the channel closes
+	stream.Unsubscribe()
+	select {
+	case <-delivered:
+	case <-time.After(testTimeout):
+		t.Fatal("delivering was not released when unsubscribing")
+	}
+
+	postings := []int{}
+	for posting := range stream.All() {
+		postings = append(postings, posting)
+	}
+	if !slices.Equal(postings, []int{1}) {
+		t.Errorf("expected the buffered [1] after unsubscribing, got %v", postings)
+	}
+
+	// Later deliveries are ignored
+	stream.deliver(3)
+}
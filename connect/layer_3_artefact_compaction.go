@@ -0,0 +1,127 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact Compaction
+ *
+ * This component keeps the deltas of JSON artefact updates from growing without bound, when a poster only posts
+ * updates. According to a compaction policy, a new state is posted instead of an update, when the delta since the
+ * state has too many operations, is too large compared to the state, or when the state has become too old.
+ * This is transparent to listeners, since the new state is followed by an (empty) update.
+ * The policy is only checked when posting an update, as compacting in between would interfere with the postings
+ * of the agent itself. So, the state of an artefact that is no longer updated is not compacted, however old it
+ * may be, and a maximum age is only enforced by the first update posted after the state has become too old.
+ * The policy is read from the "compact_max_operations", "compact_max_percentage", and "compact_max_age" (in
+ * minutes) keys in the "artefacts" section of the config file. A value of 0 means no limit.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+/*
+ * Defining compaction policies
+ */
+
+type (
+	// The policy for posting a new state, rather than an update. A limit of 0 means no limit.
+	TCompactionPolicy struct {
+		MaxOperations int           // Maximum number of delta operations since the state
+		MaxPercentage int           // Maximum size of the delta since the state, as a percentage of the size of the state
+		MaxAge        time.Duration // Maximum age of the state, when posting an update
+	}
+
+	// The progress towards the next compaction
+	tCompaction struct {
+		stateTime  time.Time // When the current state was posted
+		operations int       // Number of delta operations of the updates since the state
+		size       int       // Size of the deltas of the updates since the state
+	}
+)
+
+// Read the compaction policy from the config data
+func compactionPolicyFromConfig(configData *generics.TConfigData) TCompactionPolicy {
+	return TCompactionPolicy{
+		MaxOperations: configData.GetValue("artefacts", "compact_max_operations").IntWithDefault(0),
+		MaxPercentage: configData.GetValue("artefacts", "compact_max_percentage").IntWithDefault(0),
+		MaxAge:        time.Duration(configData.GetValue("artefacts", "compact_max_age").IntWithDefault(0)) * time.Minute,
+	}
+}
+
+// Count the operations of a delta
+func deltaOperationsCount(deltaOperationsJSON json.RawMessage) int {
+	operations := []json.RawMessage{}
+	if err := json.Unmarshal(deltaOperationsJSON, &operations); err != nil {
+		return 0
+	}
+
+	return len(operations)
+}
+
+/*
+ * Compacting
+ */
+
+// Start measuring from a newly posted state
+func (b *TModellingBusArtefactConnector) resetCompaction() {
+	b.compaction = tCompaction{stateTime: time.Now()}
+}
+
+// Add a posted update to the measurements
+func (b *TModellingBusArtefactConnector) addToCompaction(deltaOperationsJSON json.RawMessage) {
+	b.compaction.operations += deltaOperationsCount(deltaOperationsJSON)
+	b.compaction.size += len(deltaOperationsJSON)
+}
+
+// Check whether a new state should be posted, rather than the given delta towards the updated state
+func (b *TModellingBusArtefactConnector) needsCompaction(deltaOperationsJSON json.RawMessage, updatedStateJSON []byte) bool {
+	// Without operations, there is nothing to compact
+	operations := deltaOperationsCount(deltaOperationsJSON)
+	if operations == 0 {
+		return false
+	}
+
+	// Determine the delta since the state.
+	// Without chained updates, the delta already is relative to the state.
+	size := len(deltaOperationsJSON)
+	if b.ChainedUpdates {
+		operations += b.compaction.operations
+		size += b.compaction.size
+	}
+
+	// Check the limits of the policy
+	policy := b.CompactionPolicy
+	switch {
+	case policy.MaxOperations > 0 && operations > policy.MaxOperations:
+		return true
+	case policy.MaxPercentage > 0 && size*100 > policy.MaxPercentage*len(updatedStateJSON):
+		return true
+	case policy.MaxAge > 0 && time.Since(b.compaction.stateTime) > policy.MaxAge:
+		return true
+	default:
+		return false
+	}
+}
+
+// Post the updated state as a new state, followed by an empty update to inform the update listeners
+func (b *TModellingBusArtefactConnector) compactJSONArtefact(updatedStateJSON []byte) error {
+	b.ModellingBusConnector.Reporter.Progress(generics.ProgressLevelDetailed, "Compacting artefact %s, by posting its updated state as a new state.", b.ArtefactID)
+
+	// Post the new state
+	if err := b.postJSONArtefactState(updatedStateJSON); err != nil {
+		return err
+	}
+
+	// Post the empty update
+	return b.postJSONArtefactUpdate(updatedStateJSON)
+}
@@ -0,0 +1,67 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact Compaction (tests)
+ *
+ * These tests post new states of JSON artefacts, rather than updates, according to the compaction policy.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompactionOfManyOperations(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model", "[artefacts]\nchained_updates = true\ncompact_max_operations = 2")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":0}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	stateTimestamp := poster.CurrentTimestamp
+
+	// The first two operations are posted as updates
+	for _, update := range []string{`{"a":1}`, `{"a":2}`} {
+		if err := poster.TryPostJSONArtefactUpdate([]byte(update), nil); err != nil {
+			t.Fatalf("posting the update: %s", err)
+		}
+	}
+	if poster.CurrentTimestamp != stateTimestamp {
+		t.Fatal("compacted before reaching the maximum number of operations")
+	}
+
+	// While the third results in a new state
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":3}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	if poster.CurrentTimestamp == stateTimestamp {
+		t.Error("did not compact after reaching the maximum number of operations")
+	}
+	checkTestJSON(t, "current", poster.CurrentContent, `{"a":3}`)
+}
+
+func TestCompactionOfOldStatesWhenPostingUpdates(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model", "[artefacts]\ncompact_max_age = 1")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":0}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	stateTimestamp := poster.CurrentTimestamp
+
+	// Let the state become too old, which is only acted upon when posting the next update
+	poster.compaction.stateTime = time.Now().Add(-2 * time.Minute)
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	if poster.CurrentTimestamp == stateTimestamp {
+		t.Error("did not compact the old state")
+	}
+	checkTestJSON(t, "current", poster.CurrentContent, `{"a":1}`)
+}
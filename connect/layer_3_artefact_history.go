@@ -0,0 +1,241 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact History
+ *
+ * This component keeps the full version history of JSON artefacts in the repository, so that experiments remain
+ * reproducible after the fact.
+ * Next to being posted as usual, each state, update and considering posting of a JSON artefact is archived in
+ * the repository under a timestamped name. Each version is listed on a topic of its own, underneath the history
+ * topic of the artefact, so posting a version does not involve re-posting the versions before it. As archiving
+ * goes through the outbox, versions posted while the repository or event bus cannot be reached are kept as well.
+ * As the history grows with each posting, it is only kept when switched on by means of the "history" key in the
+ * "repository" section of the config file. Filling gaps in chains of updates relies on the history as well, so
+ * with chained updates, the history of the artefacts being posted is always kept.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	artefactHistoryPathElement = "history" // Artefact history path element
+)
+
+/*
+ * Defining artefact histories
+ */
+
+type (
+	// A version of a JSON artefact, i.e. a state, update, or considering posting
+	TJSONArtefactVersion struct {
+		Timestamp        string `json:"timestamp"`          // Timestamp of the posting
+		Kind             string `json:"kind"`               // Kind of posting: "state", "update", or "considering"
+		CurrentTimestamp string `json:"current timestamp"`  // Timestamp of the state the posting is based on
+		Sequence         int    `json:"sequence,omitempty"` // Sequence number of a chained update, or of the update a considering is based on
+	}
+
+	// An entry in the version history of a JSON artefact
+	tJSONArtefactHistoryEntry struct {
+		TJSONArtefactVersion
+		File tRepositoryEvent `json:"file"` // Location of the archived posting in the repository
+	}
+
+	// The version history of a JSON artefact
+	tJSONArtefactHistory struct {
+		Versions []tJSONArtefactHistoryEntry `json:"versions"` // The versions, in order of posting
+	}
+)
+
+// Defining topic paths for json artefact histories
+func (b *TModellingBusArtefactConnector) jsonArtefactsHistoryTopicPath(artefactID string) string {
+	return b.jsonArtefactsTopicPath(artefactID) +
+		"/" + artefactHistoryPathElement
+}
+
+// Determine the versions that were in effect at a given moment, being the latest state, the updates to be applied
+// to that state, and the latest considering posting based on these. The resulting considering may be nil.
+// For chained updates, all updates since the state are to be applied, and otherwise only the latest one.
+func (h *tJSONArtefactHistory) versionsAt(timestamp string) (state *tJSONArtefactHistoryEntry, updates []*tJSONArtefactHistoryEntry, considering *tJSONArtefactHistoryEntry) {
+	for i := range h.Versions {
+		version := &h.Versions[i]
+
+		// Versions posted after the given moment are not relevant
+		if generics.CompareTimestamps(version.Timestamp, timestamp) > 0 {
+			continue
+		}
+
+		switch version.Kind {
+		case artefactStatePathElement:
+			// A new state replaces everything
+			state, updates, considering = version, nil, nil
+
+		case artefactUpdatePathElement:
+			// A new update replaces what was being considered
+			if state != nil && version.CurrentTimestamp == state.Timestamp {
+				if version.Sequence == 0 {
+					updates = nil
+				}
+				updates, considering = append(updates, version), nil
+			}
+
+		case artefactConsideringPathElement:
+			if state != nil && version.CurrentTimestamp == state.Timestamp {
+				considering = version
+			}
+		}
+	}
+
+	return state, updates, considering
+}
+
+/*
+ * Maintaining artefact histories
+ */
+
+// Get the version history of a JSON artefact, as listed on the event bus.
+// When no history has been posted (yet), the result is an empty history.
+func (b *TModellingBusArtefactConnector) getJSONArtefactHistory(agentID, artefactID string) (*tJSONArtefactHistory, error) {
+	history := &tJSONArtefactHistory{}
+
+	// Get the archived versions
+	archivedPostings, err := b.ModellingBusConnector.getArchivedPostings(agentID, b.jsonArtefactsHistoryTopicPath(artefactID))
+	if err != nil {
+		return history, err
+	}
+
+	// Collect the versions
+	for archiveName, archivedPosting := range archivedPostings {
+		version := tJSONArtefactHistoryEntry{File: archivedPosting.File}
+		if err := json.Unmarshal(archivedPosting.Details, &version.TJSONArtefactVersion); err != nil {
+			return history, fmt.Errorf("unJSONing version %s: %w", archiveName, err)
+		}
+
+		history.Versions = append(history.Versions, version)
+	}
+
+	// Put the versions in order of posting
+	sort.Slice(history.Versions, func(i, j int) bool {
+		return generics.CompareTimestamps(history.Versions[i].Timestamp, history.Versions[j].Timestamp) < 0
+	})
+
+	return history, nil
+}
+
+// Archive a posting of the JSON artefact we are posting, as a version in its history
+func (b *TModellingBusArtefactConnector) archiveJSONArtefactVersion(kind, timestamp, currentTimestamp string, sequence int, postingJSON []byte) error {
+	// Only when the history is kept, or when listeners rely on it to fill gaps in chains of updates
+	if !b.ModellingBusConnector.keepHistory && !b.ChainedUpdates {
+		return nil
+	}
+
+	// The version is listed next to the archived posting
+	versionJSON, err := json.Marshal(TJSONArtefactVersion{
+		Timestamp:        timestamp,
+		Kind:             kind,
+		CurrentTimestamp: currentTimestamp,
+		Sequence:         sequence,
+	})
+	if err != nil {
+		return fmt.Errorf("JSONing the version: %w", err)
+	}
+
+	// Archive the posting under a timestamped name
+	return b.ModellingBusConnector.postArchivedJSON(b.jsonArtefactsHistoryTopicPath(b.ArtefactID), timestamp+"-"+kind, postingJSON, versionJSON, timestamp)
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Listing the versions of a JSON artefact, in order of posting
+func (b *TModellingBusArtefactConnector) TryListJSONArtefactVersions(agentID, artefactID string) ([]TJSONArtefactVersion, error) {
+	// Get the version history
+	history, err := b.getJSONArtefactHistory(agentID, artefactID)
+	if err != nil {
+		return []TJSONArtefactVersion{}, err
+	}
+
+	// Collect the versions
+	versions := []TJSONArtefactVersion{}
+	for _, version := range history.Versions {
+		versions = append(versions, version.TJSONArtefactVersion)
+	}
+
+	return versions, nil
+}
+
+// Getting a JSON artefact as it was at a given moment, including what was updated and considered at that time
+func (b *TModellingBusArtefactConnector) TryGetJSONArtefactAt(agentID, artefactID, timestamp string) error {
+	// Get the version history
+	history, err := b.getJSONArtefactHistory(agentID, artefactID)
+	if err != nil {
+		return err
+	}
+
+	// Determine the versions in effect at the given moment
+	state, updates, considering := history.versionsAt(timestamp)
+	if state == nil {
+		return fmt.Errorf("no state of artefact %s at %s: %w", artefactID, timestamp, ErrNotFound)
+	}
+
+	// Get the state
+	stateJSON, err := b.ModellingBusConnector.getArchivedJSON(state.File)
+	if err != nil {
+		return err
+	}
+	b.updateCurrentJSONArtefact(stateJSON, state.Timestamp)
+
+	// Apply the updates, in order
+	for _, update := range updates {
+		deltaJSON, err := b.ModellingBusConnector.getArchivedJSON(update.File)
+		if err != nil {
+			return err
+		}
+
+		if err := b.updateUpdatedJSONArtefact(agentID, artefactID, deltaJSON); err != nil {
+			return err
+		}
+	}
+
+	// Apply the considering, if any
+	if considering != nil {
+		deltaJSON, err := b.ModellingBusConnector.getArchivedJSON(considering.File)
+		if err != nil {
+			return err
+		}
+
+		if err := b.updateConsideringJSONArtefact(deltaJSON); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Listing the versions of a JSON artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) ListJSONArtefactVersions(agentID, artefactID string) []TJSONArtefactVersion {
+	versions, err := b.TryListJSONArtefactVersions(agentID, artefactID)
+	b.ModellingBusConnector.reportError("Something went wrong listing the artefact versions.", err)
+
+	return versions
+}
+
+// Getting a JSON artefact as it was at a given moment, reporting potential errors
+func (b *TModellingBusArtefactConnector) GetJSONArtefactAt(agentID, artefactID, timestamp string) {
+	b.ModellingBusConnector.reportError("Something went wrong retrieving the artefact version.", b.TryGetJSONArtefactAt(agentID, artefactID, timestamp))
+}
@@ -0,0 +1,139 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact History (tests)
+ *
+ * These tests keep the version history of JSON artefacts, and retrieve artefacts as they were at a given moment.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Create an artefact connector for tests, preparing it for posting the given artefact
+func createTestArtefactConnector(t *testing.T, agentID, artefactID string, extraConfig ...string) TModellingBusArtefactConnector {
+	artefacts := CreateModellingBusArtefactConnector(createTestConnector(t, agentID, extraConfig...), "v1")
+	artefacts.PrepareForPosting(artefactID)
+
+	return artefacts
+}
+
+// Check that two JSON documents are equivalent
+func checkTestJSON(t *testing.T, what string, got []byte, expected string) {
+	t.Helper()
+
+	var gotValue, expectedValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("%s: unJSONing %s: %s", what, got, err)
+	}
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotValue, expectedValue) {
+		t.Errorf("%s: expected %s, got %s", what, expected, got)
+	}
+}
+
+func TestArtefactHistoryIsOptIn(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+
+	versions, err := poster.TryListJSONArtefactVersions("poster", "model")
+	if err != nil {
+		t.Fatalf("listing: %s", err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("kept a history without being asked to: %v", versions)
+	}
+}
+
+func TestArtefactHistoryListsVersions(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model", "[repository]\nhistory = true")
+	reader := createTestArtefactConnector(t, "reader", "")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactConsidering([]byte(`{"a":3}`), nil); err != nil {
+		t.Fatalf("posting the considering: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":4}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+
+	versions, err := reader.TryListJSONArtefactVersions("poster", "model")
+	if err != nil {
+		t.Fatalf("listing: %s", err)
+	}
+	kinds := []string{}
+	for _, version := range versions {
+		kinds = append(kinds, version.Kind)
+	}
+	expectedKinds := []string{artefactStatePathElement, artefactUpdatePathElement, artefactConsideringPathElement, artefactUpdatePathElement}
+	if !reflect.DeepEqual(kinds, expectedKinds) {
+		t.Fatalf("expected %v, got %v", expectedKinds, kinds)
+	}
+
+	// Each version can be retrieved as it was at the time
+	if err := reader.TryGetJSONArtefactAt("poster", "model", versions[2].Timestamp); err != nil {
+		t.Fatalf("getting the artefact at %s: %s", versions[2].Timestamp, err)
+	}
+	checkTestJSON(t, "current", reader.CurrentContent, `{"a":1}`)
+	checkTestJSON(t, "updated", reader.UpdatedContent, `{"a":2}`)
+	checkTestJSON(t, "considered", reader.ConsideredContent, `{"a":3}`)
+
+	if err := reader.TryGetJSONArtefactAt("poster", "model", versions[3].Timestamp); err != nil {
+		t.Fatalf("getting the artefact at %s: %s", versions[3].Timestamp, err)
+	}
+	checkTestJSON(t, "updated", reader.UpdatedContent, `{"a":4}`)
+	checkTestJSON(t, "considered", reader.ConsideredContent, `{"a":4}`)
+
+	// Deleting the artefact deletes its history as well
+	if err := poster.TryDeleteJSONArtefact("model"); err != nil {
+		t.Fatalf("deleting: %s", err)
+	}
+	if versions, err := reader.TryListJSONArtefactVersions("poster", "model"); err != nil || len(versions) != 0 {
+		t.Errorf("expected no versions after deleting, got %v (%v)", versions, err)
+	}
+}
+
+func TestArtefactHistoryKeepsVersionsPostedWhileDisconnected(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model", "[repository]\nhistory = true\n\n[outbox]\nretry_interval = 3600")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+
+	// While disconnected, the update is kept in the outbox, including its version
+	poster.ModellingBusConnector.modellingBusEventsConnector.connected.Store(false)
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update while disconnected: %s", err)
+	}
+
+	poster.ModellingBusConnector.modellingBusEventsConnector.connected.Store(true)
+	poster.ModellingBusConnector.flushOutbox()
+
+	versions, err := poster.TryListJSONArtefactVersions("poster", "model")
+	if err != nil {
+		t.Fatalf("listing: %s", err)
+	}
+	if len(versions) != 2 || versions[1].Kind != artefactUpdatePathElement {
+		t.Errorf("expected the state and the update, got %v", versions)
+	}
+}
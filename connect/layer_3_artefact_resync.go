@@ -0,0 +1,198 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact Resync
+ *
+ * This component resynchronises listeners of JSON artefacts with the poster, when a received delta cannot be
+ * applied. For instance, since the listener missed a state, or since updates are missing from a chain of updates.
+ * The listener then gets the latest state, including the pending update and considering, from the bus. When this
+ * does not suffice, it asks the poster to repost its state by means of a coordination message. A poster only
+ * answers such requests when it listens for them (see ListenForJSONArtefactStateRequests), as reposting
+ * interferes with its own postings. Otherwise, the listener resynchronises with the next state that is posted.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+// Error for resynchronisations that have to wait for the poster to repost its state
+var errStateRequested = errors.New("state requested from the poster")
+
+const (
+	artefactStateRequestsCoordinationElement = "artefact-state-requests" // Coordination ID element for requests to repost the state of an artefact
+)
+
+/*
+ * Defining resync reasons
+ */
+
+type (
+	// The reason for resynchronising a JSON artefact
+	TResyncReason string
+
+	// A request to repost the state of a JSON artefact
+	tJSONArtefactStateRequest struct {
+		Requester string `json:"requester"` // The agent making the request
+	}
+)
+
+const (
+	ResyncStaleDelta     TResyncReason = "stale delta"     // The delta is based on another state than the current one
+	ResyncMissingUpdates TResyncReason = "missing updates" // Updates preceding the delta in a chain of updates are missing
+	ResyncFailedDelta    TResyncReason = "failed delta"    // The delta could not be applied to the current state
+)
+
+// Determine the reason for resynchronising, given the problem with applying a received delta
+func resyncReasonFor(err error) TResyncReason {
+	switch {
+	case errors.Is(err, ErrStaleDelta):
+		return ResyncStaleDelta
+	case errors.Is(err, ErrUpdateGap):
+		return ResyncMissingUpdates
+	default:
+		return ResyncFailedDelta
+	}
+}
+
+// Defining coordination IDs for requests to repost the state of a JSON artefact of a given agent
+func (b *TModellingBusArtefactConnector) jsonArtefactStateRequestsCoordinationID(agentID, artefactID string) string {
+	return artefactStateRequestsCoordinationElement +
+		"/" + agentID +
+		"/" + artefactID +
+		"/" + b.JSONVersion
+}
+
+/*
+ * Resynchronising
+ */
+
+// Resynchronising a JSON artefact of the given agent, after the given delta could not be applied.
+// Deltas that have already been applied need no resynchronisation, in which case the given problem is returned.
+// When the poster has been asked to repost its state, errStateRequested is returned.
+func (b *TModellingBusArtefactConnector) resyncJSONArtefact(agentID, artefactID string, deltaJSON []byte, problem error) error {
+	if errors.Is(problem, errDeltaAlreadyApplied) {
+		return problem
+	}
+
+	reason := resyncReasonFor(problem)
+	b.ModellingBusConnector.Reporter.Progress(generics.ProgressLevelDetailed, "Resynchronising artefact %s of agent %s, due to a %s.", artefactID, agentID, reason)
+
+	// Get the latest state, including the pending update and considering.
+	// Deltas based on an older state are no longer pending, so these are not a problem.
+	err := b.deltaError(b.TryGetJSONArtefactConsidering(agentID, artefactID))
+
+	// Check whether we have caught up with the state the delta is based on
+	if delta, parseErr := b.parseJSONDelta(deltaJSON); err == nil && parseErr == nil &&
+		generics.CompareTimestamps(b.CurrentTimestamp, delta.CurrentTimestamp) < 0 {
+		err = fmt.Errorf("the latest state %s precedes state %s of the delta", b.CurrentTimestamp, delta.CurrentTimestamp)
+	}
+
+	// When still not in sync, ask the poster to repost its state
+	if err != nil {
+		b.ModellingBusConnector.Reporter.Progress(generics.ProgressLevelDetailed, "Asking agent %s to repost artefact %s. %s", agentID, artefactID, err)
+
+		if err := b.TryRequestJSONArtefactState(agentID, artefactID); err != nil {
+			return err
+		}
+
+		return errStateRequested
+	}
+
+	// Notify the agent
+	if b.resyncHandler != nil {
+		b.resyncHandler(reason)
+	}
+
+	return nil
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Set the handler to be called when a JSON artefact has been resynchronised, after a received delta could not
+// be applied. The content of the artefact connector then reflects the latest state, update, and considering.
+func (b *TModellingBusArtefactConnector) SetResyncHandler(resyncHandler func(reason TResyncReason)) {
+	b.resyncHandler = resyncHandler
+}
+
+// Requesting the given agent to repost the state of a JSON artefact.
+// The agent only reposts its state when it listens for such requests (see ListenForJSONArtefactStateRequests).
+func (b *TModellingBusArtefactConnector) TryRequestJSONArtefactState(agentID, artefactID string) error {
+	// Create the request
+	requestJSON, err := json.Marshal(tJSONArtefactStateRequest{Requester: b.ModellingBusConnector.agentID})
+	if err != nil {
+		return fmt.Errorf("JSONing the state request: %w", err)
+	}
+
+	// Post the request
+	return b.ModellingBusConnector.TryPostCoordination(b.jsonArtefactStateRequestsCoordinationID(agentID, artefactID), requestJSON)
+}
+
+// Listening for requests from other agents to repost the state of the JSON artefact we are posting.
+// The handler is given the agent making the request, and would normally call RepostJSONArtefactState.
+// As reposting changes the content of the artefact connector, the handler should not repost while the poster
+// is posting itself.
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStateRequests(handler func(requester string)) *Subscription {
+	// Listen for requests from all agents
+	return b.ModellingBusConnector.ListenForCoordinationPostings("+", b.jsonArtefactStateRequestsCoordinationID(b.ModellingBusConnector.agentID, b.ArtefactID), func(requestJSON []byte, _ string) {
+		request := tJSONArtefactStateRequest{}
+		if err := json.Unmarshal(requestJSON, &request); err != nil {
+			b.ModellingBusConnector.reportError("Something went wrong reading the state request.", fmt.Errorf("unJSONing the state request: %w", err))
+			return
+		}
+
+		handler(request.Requester)
+	})
+}
+
+// Reposting the state of the JSON artefact we are posting, as well as the pending update and considering
+func (b *TModellingBusArtefactConnector) TryRepostJSONArtefactState() error {
+	// Keep the pending update and considering
+	updatedContent := b.UpdatedContent
+	consideredContent := b.ConsideredContent
+
+	// Repost the state
+	if err := b.postJSONArtefactState(b.CurrentContent); err != nil {
+		return err
+	}
+
+	// Repost the update, if any
+	if !bytes.Equal(updatedContent, b.CurrentContent) {
+		if err := b.postJSONArtefactUpdate(updatedContent); err != nil {
+			return err
+		}
+	}
+
+	// Repost the considering, if any
+	if !bytes.Equal(consideredContent, updatedContent) {
+		return b.postJSONArtefactConsidering(consideredContent)
+	}
+
+	return nil
+}
+
+// Requesting the given agent to repost the state of a JSON artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) RequestJSONArtefactState(agentID, artefactID string) {
+	b.ModellingBusConnector.reportError("Something went wrong requesting the artefact state.", b.TryRequestJSONArtefactState(agentID, artefactID))
+}
+
+// Reposting the state of the JSON artefact we are posting, reporting potential errors
+func (b *TModellingBusArtefactConnector) RepostJSONArtefactState() {
+	b.ModellingBusConnector.reportError("Something went wrong reposting the artefact state.", b.TryRepostJSONArtefactState())
+}
@@ -0,0 +1,139 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact Resync (tests)
+ *
+ * These tests resynchronise listeners of JSON artefacts with the poster, after a received delta could not be applied.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+// The content of a listener after handling a posting
+type tTestListenerContent struct {
+	timestamp string          // The current timestamp
+	current   json.RawMessage // The current content
+	updated   json.RawMessage // The updated content
+}
+
+func TestResyncAfterAStaleDelta(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+	listener := createTestArtefactConnector(t, "listener", "")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := listener.TryGetJSONArtefactState("poster", "model"); err != nil {
+		t.Fatalf("getting the state: %s", err)
+	}
+
+	reasons := make(chan TResyncReason, 1)
+	listener.SetResyncHandler(func(reason TResyncReason) { reasons <- reason })
+	contents := make(chan tTestListenerContent, 10)
+	subscription := listener.ListenForJSONArtefactUpdatePostings("poster", "model", func() {
+		contents <- tTestListenerContent{timestamp: listener.CurrentTimestamp, current: listener.CurrentContent, updated: listener.UpdatedContent}
+	})
+	defer subscription.Unsubscribe()
+
+	// The listener misses the new state, so the update is based on a state it does not have
+	if err := poster.TryPostJSONArtefactState([]byte(`{"b":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"b":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+
+	select {
+	case reason := <-reasons:
+		if reason != ResyncStaleDelta {
+			t.Errorf("expected to resynchronise due to a stale delta, got %s", reason)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("did not resynchronise in time")
+	}
+
+	content := <-contents
+	checkTestJSON(t, "current", content.current, `{"b":1}`)
+	checkTestJSON(t, "updated", content.updated, `{"b":2}`)
+}
+
+func TestResyncRequestsTheStateFromThePoster(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+	listener := createTestArtefactConnector(t, "listener", "")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	stateTimestamp := poster.CurrentTimestamp
+
+	// The poster answers requests to repost its state
+	requesters := make(chan string, 10)
+	requestSubscription := poster.ListenForJSONArtefactStateRequests(func(requester string) {
+		poster.RepostJSONArtefactState()
+		requesters <- requester
+	})
+	defer requestSubscription.Unsubscribe()
+
+	contents := make(chan tTestListenerContent, 10)
+	stateSubscription := listener.ListenForJSONArtefactStatePostings("poster", "model", func() {
+		contents <- tTestListenerContent{timestamp: listener.CurrentTimestamp, current: listener.CurrentContent}
+	})
+	defer stateSubscription.Unsubscribe()
+	updateSubscription := listener.ListenForJSONArtefactUpdatePostings("poster", "model", func() {
+		contents <- tTestListenerContent{timestamp: listener.CurrentTimestamp, current: listener.CurrentContent, updated: listener.UpdatedContent}
+	})
+	defer updateSubscription.Unsubscribe()
+
+	// A delta based on a state that is not on the bus, so the latest state does not suffice
+	deltaJSON, err := json.Marshal(TJSONDelta{Timestamp: generics.GetTimestamp(), CurrentTimestamp: generics.GetTimestamp(), Operations: json.RawMessage(`[]`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.listenerMutex.Lock()
+	err = listener.resyncJSONArtefact("poster", "model", deltaJSON, ErrStaleDelta)
+	listener.listenerMutex.Unlock()
+	if !errors.Is(err, errStateRequested) {
+		t.Fatalf("expected the state to be requested, got %v", err)
+	}
+
+	select {
+	case requester := <-requesters:
+		if requester != "listener" {
+			t.Errorf("expected the request from the listener, got %s", requester)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("the poster did not receive the request in time")
+	}
+
+	// The listener receives the reposted state, followed by the reposted update
+	deadline := time.After(testTimeout)
+	for {
+		select {
+		case content := <-contents:
+			if content.timestamp != stateTimestamp && content.updated != nil {
+				checkTestJSON(t, "current", content.current, `{"a":1}`)
+				checkTestJSON(t, "updated", content.updated, `{"a":2}`)
+				return
+			}
+		case <-deadline:
+			t.Fatal("the reposted state did not arrive in time")
+		}
+	}
+}
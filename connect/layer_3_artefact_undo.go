@@ -0,0 +1,231 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact Undo
+ *
+ * This component keeps an undo/redo history of the updates and considering postings of the JSON artefact being
+ * posted. Each step in the history holds the JSON patches to undo and redo the changes to the updated and
+ * considered content. Undoing and redoing a step posts the resulting update or considering.
+ * As an update also replaces what was being considered, undoing an update restores both the previous update and
+ * the previous considering, so that undo works across the boundary between considering and updating.
+ * Posting a new state starts a new history. The number of steps kept can be set by means of the "undo_steps" key
+ * in the "artefacts" section of the config file.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	defaultUndoSteps = 100 // Default number of steps kept in the undo history
+)
+
+/*
+ * Defining undo histories
+ */
+
+type (
+	// A step in the undo history, being an update or a considering
+	tUndoStep struct {
+		kind string // Kind of posting: "update" or "considering"
+
+		undoUpdated json.RawMessage // Patch to undo the changes to the updated content, for updates
+		redoUpdated json.RawMessage // Patch to redo the changes to the updated content, for updates
+
+		undoConsidered json.RawMessage // Patch to undo the changes to the considered content
+		redoConsidered json.RawMessage // Patch to redo the changes to the considered content
+	}
+)
+
+// Determine the patches to redo and undo the changes from an old to a new JSON state
+func (b *TModellingBusArtefactConnector) undoRedoPatches(oldStateJSON, newStateJSON []byte) (json.RawMessage, json.RawMessage, error) {
+	redoPatchJSON, err := b.diffJSON(oldStateJSON, newStateJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	undoPatchJSON, err := generics.JSONInvertPatch(oldStateJSON, redoPatchJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inverting the diff patch: %w", err)
+	}
+
+	return undoPatchJSON, redoPatchJSON, nil
+}
+
+/*
+ * Maintaining undo histories
+ */
+
+// Forget the undo history
+func (b *TModellingBusArtefactConnector) clearUndoHistory() {
+	b.undoSteps = []tUndoStep{}
+	b.redoSteps = []tUndoStep{}
+}
+
+// Post an update or considering, and add it as a step to the undo history
+func (b *TModellingBusArtefactConnector) recordUndoStep(kind string, post func() error) error {
+	// Keep the content from before the posting
+	oldUpdatedContent := b.UpdatedContent
+	oldConsideredContent := b.ConsideredContent
+
+	// Post
+	if err := post(); err != nil {
+		return err
+	}
+
+	// Before the state has been communicated, there is nothing to undo
+	if len(oldUpdatedContent) == 0 || len(oldConsideredContent) == 0 {
+		return nil
+	}
+
+	// Determine the patches
+	step := tUndoStep{kind: kind}
+	if kind == artefactUpdatePathElement {
+		var err error
+		step.undoUpdated, step.redoUpdated, err = b.undoRedoPatches(oldUpdatedContent, b.UpdatedContent)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	step.undoConsidered, step.redoConsidered, err = b.undoRedoPatches(oldConsideredContent, b.ConsideredContent)
+	if err != nil {
+		return err
+	}
+
+	// Postings without changes need no undoing
+	if deltaOperationsCount(step.redoUpdated) == 0 && deltaOperationsCount(step.redoConsidered) == 0 {
+		return nil
+	}
+
+	// Add the step, forgetting the oldest steps when needed
+	b.undoSteps = append(b.undoSteps, step)
+	if len(b.undoSteps) > b.maxUndoSteps {
+		b.undoSteps = b.undoSteps[len(b.undoSteps)-b.maxUndoSteps:]
+	}
+
+	// A new step cannot be combined with the steps undone before
+	b.redoSteps = []tUndoStep{}
+
+	return nil
+}
+
+// Apply the patches of an undo step to the updated and considered content, and post the results
+func (b *TModellingBusArtefactConnector) replayUndoStep(kind string, updatedPatchJSON, consideredPatchJSON []byte) error {
+	// Determine the resulting considered content
+	consideredContent, err := generics.JSONApplyPatch(b.ConsideredContent, consideredPatchJSON)
+	if err != nil {
+		return fmt.Errorf("applying patch: %w", err)
+	}
+
+	// For considerings, only the considered content changes
+	if kind == artefactConsideringPathElement {
+		return b.postJSONArtefactConsidering(consideredContent)
+	}
+
+	// Determine the resulting updated content
+	updatedContent, err := generics.JSONApplyPatch(b.UpdatedContent, updatedPatchJSON)
+	if err != nil {
+		return fmt.Errorf("applying patch: %w", err)
+	}
+
+	// Post the update, followed by what was being considered on top of it, if anything
+	if err := b.postJSONArtefactUpdate(updatedContent); err != nil {
+		return err
+	}
+
+	differences, err := b.diffJSON(updatedContent, consideredContent)
+	if err != nil {
+		return err
+	}
+
+	if deltaOperationsCount(differences) > 0 {
+		return b.postJSONArtefactConsidering(consideredContent)
+	}
+
+	return nil
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Checking whether there is an update or considering to undo
+func (b *TModellingBusArtefactConnector) CanUndo() bool {
+	return len(b.undoSteps) > 0
+}
+
+// Checking whether there is an undone update or considering to redo
+func (b *TModellingBusArtefactConnector) CanRedo() bool {
+	return len(b.redoSteps) > 0
+}
+
+// Undoing the last update or considering, by posting the previous update and/or considering
+func (b *TModellingBusArtefactConnector) TryUndo() error {
+	if !b.CanUndo() {
+		return fmt.Errorf("nothing to undo: %w", ErrNotFound)
+	}
+
+	// Undo the last step
+	step := b.undoSteps[len(b.undoSteps)-1]
+	if err := b.replayUndoStep(step.kind, step.undoUpdated, step.undoConsidered); err != nil {
+		return fmt.Errorf("undoing the %s: %w", step.kind, err)
+	}
+
+	// Move the step to the redo history
+	b.undoSteps = b.undoSteps[:len(b.undoSteps)-1]
+	b.redoSteps = append(b.redoSteps, step)
+
+	return nil
+}
+
+// Redoing the last undone update or considering
+func (b *TModellingBusArtefactConnector) TryRedo() error {
+	if !b.CanRedo() {
+		return fmt.Errorf("nothing to redo: %w", ErrNotFound)
+	}
+
+	// Redo the last undone step
+	step := b.redoSteps[len(b.redoSteps)-1]
+	if err := b.replayUndoStep(step.kind, step.redoUpdated, step.redoConsidered); err != nil {
+		return fmt.Errorf("redoing the %s: %w", step.kind, err)
+	}
+
+	// Move the step back to the undo history
+	b.redoSteps = b.redoSteps[:len(b.redoSteps)-1]
+	b.undoSteps = append(b.undoSteps, step)
+
+	return nil
+}
+
+// Undoing the last update or considering, reporting potential errors.
+// The result is whether a step was undone.
+func (b *TModellingBusArtefactConnector) Undo() bool {
+	err := b.TryUndo()
+	b.ModellingBusConnector.reportError("Something went wrong undoing the artefact change.", err)
+
+	return err == nil
+}
+
+// Redoing the last undone update or considering, reporting potential errors.
+// The result is whether a step was redone.
+func (b *TModellingBusArtefactConnector) Redo() bool {
+	err := b.TryRedo()
+	b.ModellingBusConnector.reportError("Something went wrong redoing the artefact change.", err)
+
+	return err == nil
+}
@@ -0,0 +1,114 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefact Undo (tests)
+ *
+ * These tests undo and redo updates and considerings of JSON artefacts.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUndoRedoUpdates(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	for _, update := range []string{`{"a":2}`, `{"a":2,"b":1}`} {
+		if err := poster.TryPostJSONArtefactUpdate([]byte(update), nil); err != nil {
+			t.Fatalf("posting the update: %s", err)
+		}
+	}
+
+	// Undo both updates
+	for _, expected := range []string{`{"a":2}`, `{"a":1}`} {
+		if !poster.Undo() {
+			t.Fatal("nothing undone")
+		}
+		checkTestJSON(t, "updated", poster.UpdatedContent, expected)
+		checkTestJSON(t, "considered", poster.ConsideredContent, expected)
+	}
+
+	// Beyond the state, there is nothing left to undo
+	if poster.CanUndo() || poster.Undo() {
+		t.Error("undone beyond the state")
+	}
+
+	// Redo the first update, after which a new update forgets the second one
+	if !poster.Redo() {
+		t.Fatal("nothing redone")
+	}
+	checkTestJSON(t, "updated", poster.UpdatedContent, `{"a":2}`)
+
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":3}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	if poster.CanRedo() || poster.Redo() {
+		t.Error("redone a step after a new update")
+	}
+}
+
+func TestUndoRedoConsiderings(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+	listener := createTestArtefactConnector(t, "listener", "")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactConsidering([]byte(`{"a":1,"b":1}`), nil); err != nil {
+		t.Fatalf("posting the considering: %s", err)
+	}
+
+	// Undoing a considering only affects the considered content, which is posted for the listeners as well
+	if !poster.Undo() {
+		t.Fatal("nothing undone")
+	}
+	checkTestJSON(t, "updated", poster.UpdatedContent, `{"a":1}`)
+	checkTestJSON(t, "considered", poster.ConsideredContent, `{"a":1}`)
+
+	if err := listener.TryGetJSONArtefactConsidering("poster", "model"); err != nil {
+		t.Fatalf("getting the considering: %s", err)
+	}
+	checkTestJSON(t, "listened", listener.ConsideredContent, `{"a":1}`)
+
+	if !poster.Redo() {
+		t.Fatal("nothing redone")
+	}
+	checkTestJSON(t, "considered", poster.ConsideredContent, `{"a":1,"b":1}`)
+}
+
+func TestUndoWithoutSteps(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+
+	if err := poster.TryUndo(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := poster.TryRedo(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// A new state starts a new undo history
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":3}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if poster.Undo() {
+		t.Error("undone an update from before the state")
+	}
+}
@@ -5,10 +5,15 @@
  * Component: Layer 3 - Artefacts
  *
  * This component provides the functionality to manage artefacts on the BIG Modelling Bus.
+ * By default, each update of a JSON artefact is a delta with regard to its current state, so that an update
+ * replaces the previous one. With chained updates (see the "chained_updates" key in the "artefacts" section of
+ * the config file), each update is a delta with regard to the previous update instead. The updates are then
+ * numbered, and refer to their predecessor, so that listeners can apply them in order, and detect missing ones.
+ * Missing updates are taken from the version history of the artefact, which is therefore kept for chained updates.
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 16.10.2026
  *
  */
 
@@ -16,10 +21,16 @@ package connect
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/erikproper/big-modelling-bus.go.v1/generics"
 )
 
+// Error for received deltas that have already been applied, e.g. when filling a gap in a chain of updates
+var errDeltaAlreadyApplied = errors.New("delta already applied")
+
 const (
 	jsonArtefactsPathElement = "artefacts/json" // JSON artefacts path element
 	rawArtefactsPathElement  = "artefacts/raw"  // Raw artefacts path element
@@ -41,9 +52,31 @@ type (
 		UpdatedContent    json.RawMessage `json:"-"`       // The updated content of the artefact
 		ConsideredContent json.RawMessage `json:"-"`       // The considered content of the artefact
 
+		ChainedUpdates bool `json:"-"` // Whether each update is a delta with regard to the previous update
+
 		// Before we can communicate updates or considering postings, we must have
 		// communicated the state of the model first
 		stateCommunicated bool `json:"-"` // Identenfies whether the state has been communicated
+
+		updateSequence  int    `json:"-"` // Sequence number of the last chained update since the current state
+		updateTimestamp string `json:"-"` // Timestamp of the last chained update since the current state
+
+		resyncHandler func(TResyncReason) `json:"-"` // Handler to be called after resynchronising
+
+		CompactionPolicy TCompactionPolicy `json:"-"` // When to post a new state, rather than an update
+		compaction       tCompaction       `json:"-"` // Progress towards the next compaction
+
+		sharedVersions     map[string]json.RawMessage      `json:"-"` // Content of recent versions of a shared artefact
+		sharedVersionOrder []string                        `json:"-"` // The recent versions of a shared artefact, oldest first
+		maxSharedVersions  int                             `json:"-"` // Number of recent versions of a shared artefact to be kept
+		conflictHandler    func([]TSharedArtefactConflict) `json:"-"` // Handler to be called for conflicting shared updates
+		sharedMutex        *sync.Mutex                     `json:"-"` // Guards the content and versions of a shared artefact
+
+		listenerMutex *sync.Mutex `json:"-"` // Ensures the listeners of the artefact connector handle one posting at a time
+
+		undoSteps    []tUndoStep `json:"-"` // Steps that can be undone, oldest first
+		redoSteps    []tUndoStep `json:"-"` // Steps that have been undone, most recently undone last
+		maxUndoSteps int         `json:"-"` // Number of steps to be kept in the undo history
 	}
 )
 
@@ -91,67 +124,78 @@ type TJSONDelta struct {
 	Operations       json.RawMessage `json:"operations"`        // The JSON delta operations
 	Timestamp        string          `json:"timestamp"`         // Timestamp of the delta
 	CurrentTimestamp string          `json:"current timestamp"` // The current timestamp at the sender side
-}
 
-// Posting JSON delta
-func (b *TModellingBusArtefactConnector) postJSONDelta(deltaTopicPath string, oldStateJSON, newStateJSON []byte, err error) {
-	// Check for errors
-	if err != nil {
-		b.ModellingBusConnector.Reporter.Error("Something went wrong when converting to JSON. %s", err)
-		return
-	}
+	// For chained updates, the sequence number of the update since the current state, and the timestamp of the
+	// preceding update (if any). For considering postings, the update they are based on.
+	Sequence          int    `json:"sequence,omitempty"`
+	PreviousTimestamp string `json:"previous timestamp,omitempty"`
+}
 
-	// Create the delta
+// Determining the JSON delta operations between two JSON states
+func (b *TModellingBusArtefactConnector) diffJSON(oldStateJSON, newStateJSON []byte) (json.RawMessage, error) {
 	deltaOperationsJSON, err := generics.JSONDiff(oldStateJSON, newStateJSON)
 	if err != nil {
-		b.ModellingBusConnector.Reporter.Error("Something went wrong running the JSON diff. %s", err)
-		return
+		return nil, fmt.Errorf("running the JSON diff: %w", err)
 	}
 
+	return deltaOperationsJSON, nil
+}
+
+// Posting JSON delta, where the kind is either an update or a considering.
+// The resulting timestamp of the delta is empty, when the delta could not be posted.
+func (b *TModellingBusArtefactConnector) postJSONDelta(deltaTopicPath, kind string, sequence int, previousTimestamp string, deltaOperationsJSON json.RawMessage) (string, error) {
 	// Create the delta object
 	delta := TJSONDelta{}
 	delta.Timestamp = generics.GetTimestamp()
 	delta.CurrentTimestamp = b.CurrentTimestamp
 	delta.Operations = deltaOperationsJSON
+	delta.Sequence = sequence
+	delta.PreviousTimestamp = previousTimestamp
 
 	// Convert the delta to JSON
 	deltaJSON, err := json.Marshal(delta)
 	if err != nil {
-		b.ModellingBusConnector.Reporter.Error("Something went wrong JSONing the diff patch. %s", err)
-		return
+		return "", fmt.Errorf("JSONing the diff patch: %w", err)
 	}
 
 	// Post the delta JSON
-	b.ModellingBusConnector.postJSONAsFile(deltaTopicPath, deltaJSON, delta.Timestamp)
-}
+	err = b.ModellingBusConnector.postJSONAsFile(deltaTopicPath, deltaJSON, delta.Timestamp)
+	if err != nil {
+		return "", err
+	}
 
-// Applying a JSON delta to a given current JSON state
-func (b *TModellingBusArtefactConnector) applyJSONDelta(currentJSONState json.RawMessage, deltaJSON []byte) (json.RawMessage, bool) {
-	// Unmarshal the delta
+	// Keep the delta in the version history
+	return delta.Timestamp, b.archiveJSONArtefactVersion(kind, delta.Timestamp, delta.CurrentTimestamp, delta.Sequence, deltaJSON)
+}
 
+// Unmarshalling a received JSON delta
+func (b *TModellingBusArtefactConnector) parseJSONDelta(deltaJSON []byte) (TJSONDelta, error) {
 	delta := TJSONDelta{}
 	err := json.Unmarshal(deltaJSON, &delta)
 	if err != nil {
-		b.ModellingBusConnector.Reporter.Error("Something went wrong unJSONing the received diff patch. %s", err)
-		return currentJSONState, false
+		return delta, fmt.Errorf("unJSONing the received diff patch: %w", err)
 	}
 
+	return delta, nil
+}
+
+// Applying a JSON delta to a given current JSON state
+func (b *TModellingBusArtefactConnector) applyJSONDelta(currentJSONState json.RawMessage, delta TJSONDelta) (json.RawMessage, error) {
 	// Check whether the delta can be applied
 	if delta.CurrentTimestamp != b.CurrentTimestamp {
 		// When the timestamps don't match, we cannot apply the delta
-		return currentJSONState, false
+		return currentJSONState, fmt.Errorf("delta based on state %s, while the current state is %s: %w", delta.CurrentTimestamp, b.CurrentTimestamp, ErrStaleDelta)
 	}
 
 	// Apply the delta
 	newJSONState, err := generics.JSONApplyPatch(currentJSONState, delta.Operations)
 	if err != nil {
-		// When applying the patch didn't work, we report a warning, and return the current state
-		b.ModellingBusConnector.Reporter.Error("Applying patch didn't work. %s", err)
-		return currentJSONState, false
+		// When applying the patch didn't work, we return the current state
+		return currentJSONState, fmt.Errorf("applying patch: %w", err)
 	}
 
 	// Return the new state
-	return newJSONState, true
+	return newJSONState, nil
 }
 
 // Updating the current JSON artefact state
@@ -161,41 +205,262 @@ func (b *TModellingBusArtefactConnector) updateCurrentJSONArtefact(json []byte,
 	b.UpdatedContent = json
 	b.ConsideredContent = json
 	b.CurrentTimestamp = currentTimestamp
+
+	// A new state starts a new chain of updates
+	b.updateSequence = 0
+	b.updateTimestamp = ""
 }
 
-// Updating the updated JSON artefact state
-func (b *TModellingBusArtefactConnector) updateUpdatedJSONArtefact(json []byte, _ ...string) bool {
-	// Apply the delta to the current content
-	ok := false
-	b.UpdatedContent, ok = b.applyJSONDelta(b.CurrentContent, json)
-	if ok {
-		b.ConsideredContent = b.UpdatedContent
+// Checking whether a received state is not newer than the current state. As the listeners of an artefact
+// connector handle their postings independently, a state may arrive after a delta based on it, which already
+// made us catch up with that state (see resyncJSONArtefact). Such a state should not undo the delta.
+func (b *TModellingBusArtefactConnector) isOutdatedState(currentTimestamp string) bool {
+	return len(b.CurrentContent) > 0 && generics.CompareTimestamps(currentTimestamp, b.CurrentTimestamp) <= 0
+}
+
+// Updating the updated JSON artefact state, given a received delta of the given agent and artefact
+func (b *TModellingBusArtefactConnector) updateUpdatedJSONArtefact(agentID, artefactID string, deltaJSON []byte) error {
+	// Unmarshal the delta
+	delta, err := b.parseJSONDelta(deltaJSON)
+	if err != nil {
+		return err
+	}
+
+	// Unchained updates are applied to the current content
+	if delta.Sequence == 0 {
+		updatedContent, err := b.applyJSONDelta(b.CurrentContent, delta)
+		if err != nil {
+			return err
+		}
+
+		// Update the updated and considered content
+		b.UpdatedContent = updatedContent
+		b.ConsideredContent = updatedContent
+
+		return nil
+	}
+
+	// Chained updates are applied to the updated content, in order
+	switch {
+	case delta.CurrentTimestamp != b.CurrentTimestamp:
+		// Based on another state, which is handled when applying the delta
+
+	case delta.Sequence <= b.updateSequence:
+		// Already applied, e.g. when filling a gap
+		return fmt.Errorf("update %d: %w", delta.Sequence, errDeltaAlreadyApplied)
+
+	case delta.Sequence > b.updateSequence+1:
+		// Earlier updates are missing, so we first need to apply these
+		if err := b.fillUpdateGap(agentID, artefactID, delta.Sequence); err != nil {
+			return err
+		}
+	}
+
+	return b.applyChainedUpdate(delta)
+}
+
+// Applying the next update in a chain of updates
+func (b *TModellingBusArtefactConnector) applyChainedUpdate(delta TJSONDelta) error {
+	// Check whether the update refers to the last applied update
+	if delta.CurrentTimestamp == b.CurrentTimestamp && (delta.Sequence != b.updateSequence+1 || delta.PreviousTimestamp != b.updateTimestamp) {
+		return fmt.Errorf("update %d follows %s, while the last applied update is %d (%s): %w", delta.Sequence, delta.PreviousTimestamp, b.updateSequence, b.updateTimestamp, ErrUpdateGap)
+	}
+
+	// Apply the delta to the updated content
+	updatedContent, err := b.applyJSONDelta(b.UpdatedContent, delta)
+	if err != nil {
+		return err
+	}
+
+	// Update the updated and considered content, and move along the chain
+	b.UpdatedContent = updatedContent
+	b.ConsideredContent = updatedContent
+	b.updateSequence = delta.Sequence
+	b.updateTimestamp = delta.Timestamp
+
+	return nil
+}
+
+// Filling a gap in a chain of updates up to a given sequence number, by means of the version history
+func (b *TModellingBusArtefactConnector) fillUpdateGap(agentID, artefactID string, sequence int) error {
+	// Get the version history
+	history, err := b.getJSONArtefactHistory(agentID, artefactID)
+	if err != nil {
+		return fmt.Errorf("getting the version history to fill the gap before update %d: %w", sequence, errors.Join(err, ErrUpdateGap))
+	}
+
+	// Apply the missing updates, in order
+	for _, version := range history.Versions {
+		if version.Kind == artefactUpdatePathElement && version.CurrentTimestamp == b.CurrentTimestamp &&
+			version.Sequence > b.updateSequence && version.Sequence < sequence {
+			// Get the missing update
+			deltaJSON, err := b.ModellingBusConnector.getArchivedJSON(version.File)
+			if err != nil {
+				return fmt.Errorf("getting update %d to fill the gap: %w", version.Sequence, errors.Join(err, ErrUpdateGap))
+			}
+
+			delta, err := b.parseJSONDelta(deltaJSON)
+			if err != nil {
+				return err
+			}
+
+			// Apply the missing update
+			if err := b.applyChainedUpdate(delta); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Check whether the gap has been filled
+	if b.updateSequence != sequence-1 {
+		return fmt.Errorf("updates %d to %d are not in the version history: %w", b.updateSequence+1, sequence-1, ErrUpdateGap)
 	}
 
-	// Return whether the update was successful
-	return ok
+	return nil
 }
 
 // Updating the considered JSON artefact state
-func (b *TModellingBusArtefactConnector) updateConsideringJSONArtefact(json []byte, _ ...string) bool {
+func (b *TModellingBusArtefactConnector) updateConsideringJSONArtefact(deltaJSON []byte) error {
+	// Unmarshal the delta
+	delta, err := b.parseJSONDelta(deltaJSON)
+	if err != nil {
+		return err
+	}
+
+	// Check whether the delta is based on the last applied update
+	if delta.CurrentTimestamp == b.CurrentTimestamp && delta.Sequence != b.updateSequence {
+		return fmt.Errorf("considering based on update %d, while the last applied update is %d: %w", delta.Sequence, b.updateSequence, ErrStaleDelta)
+	}
+
 	// Apply the delta to the updated content
-	ok := false
-	b.ConsideredContent, ok = b.applyJSONDelta(b.UpdatedContent, json)
+	consideredContent, err := b.applyJSONDelta(b.UpdatedContent, delta)
+	if err != nil {
+		return err
+	}
+
+	// Update the considered content
+	b.ConsideredContent = consideredContent
+
+	return nil
+}
+
+// Filtering problems with received deltas. Stale deltas, deltas that have already been applied, and deltas for
+// which the state has been requested from the poster, are to be expected, and are therefore not reported.
+func (b *TModellingBusArtefactConnector) deltaError(err error) error {
+	if errors.Is(err, ErrStaleDelta) || errors.Is(err, errDeltaAlreadyApplied) || errors.Is(err, errStateRequested) {
+		return nil
+	}
+
+	return err
+}
+
+// Reporting problems with received deltas
+func (b *TModellingBusArtefactConnector) reportDeltaError(err error) {
+	b.ModellingBusConnector.reportError("Something went wrong applying the received delta.", b.deltaError(err))
+}
+
+// Posting JSON artefact state
+func (b *TModellingBusArtefactConnector) postJSONArtefactState(stateJSON []byte) error {
+	// Post the JSON artefact state
+	b.CurrentTimestamp = generics.GetTimestamp()
+	b.CurrentContent = stateJSON
+	b.UpdatedContent = stateJSON
+	b.ConsideredContent = stateJSON
+	err := b.ModellingBusConnector.postJSONAsFile(b.jsonArtefactsStateTopicPath(b.ArtefactID), b.CurrentContent, b.CurrentTimestamp)
+	if err != nil {
+		return err
+	}
+
+	// Mark that the state has been communicated
+	b.stateCommunicated = true
+
+	// A new state starts a new chain of updates
+	b.updateSequence = 0
+	b.updateTimestamp = ""
+	b.resetCompaction()
+
+	// Keep the state in the version history
+	return b.archiveJSONArtefactVersion(artefactStatePathElement, b.CurrentTimestamp, b.CurrentTimestamp, 0, b.CurrentContent)
+}
+
+// Posting JSON artefact update
+func (b *TModellingBusArtefactConnector) postJSONArtefactUpdate(updatedStateJSON []byte) error {
+	// Ensure the state has been communicated
+	if !b.stateCommunicated {
+		if err := b.postJSONArtefactState(updatedStateJSON); err != nil {
+			return err
+		}
+	}
+
+	// Determine the delta, with regard to the current state, or to the previous update for chained updates
+	baseStateJSON := b.CurrentContent
+	if b.ChainedUpdates {
+		baseStateJSON = b.UpdatedContent
+	}
+	deltaOperationsJSON, err := b.diffJSON(baseStateJSON, updatedStateJSON)
+	if err != nil {
+		return err
+	}
+
+	// Post a new state instead, when the compaction policy says so
+	if b.needsCompaction(deltaOperationsJSON, updatedStateJSON) {
+		return b.compactJSONArtefact(updatedStateJSON)
+	}
+
+	// Post the JSON artefact update, as a delta with regard to the current state, or to the previous update for chained updates
+	sequence, previousTimestamp := 0, ""
+	if b.ChainedUpdates {
+		sequence, previousTimestamp = b.updateSequence+1, b.updateTimestamp
+	}
+	updateTimestamp, err := b.postJSONDelta(b.jsonArtefactsUpdateTopicPath(b.ArtefactID), artefactUpdatePathElement, sequence, previousTimestamp, deltaOperationsJSON)
+
+	// Only move along, once the update has been posted
+	if updateTimestamp != "" {
+		b.UpdatedContent = updatedStateJSON
+		b.ConsideredContent = updatedStateJSON
+		b.addToCompaction(deltaOperationsJSON)
+
+		if b.ChainedUpdates {
+			b.updateSequence = sequence
+			b.updateTimestamp = updateTimestamp
+		}
+	}
+
+	return err
+}
+
+// Posting JSON considered artefact
+func (b *TModellingBusArtefactConnector) postJSONArtefactConsidering(consideringStateJSON []byte) error {
+	// Ensure the state has been communicated
+	if !b.stateCommunicated {
+		if err := b.postJSONArtefactState(b.CurrentContent); err != nil {
+			return err
+		}
+	}
+
+	// Post the JSON considered artefact
+	b.ConsideredContent = consideringStateJSON
+
+	// Post the JSON considered artefact, referring to the update it is based on
+	deltaOperationsJSON, err := b.diffJSON(b.UpdatedContent, b.ConsideredContent)
+	if err != nil {
+		return err
+	}
 
-	// Return whether the update was successful
-	return ok
+	_, err = b.postJSONDelta(b.jsonArtefactsConsideringTopicPath(b.ArtefactID), artefactConsideringPathElement, b.updateSequence, b.updateTimestamp, deltaOperationsJSON)
+
+	return err
 }
 
 // Checking for JSON issues
-func (b *TModellingBusArtefactConnector) foundJSONIssue(err error) bool {
+func (b *TModellingBusArtefactConnector) foundJSONIssue(err error) error {
 	// Check for errors
 	if err != nil {
-		b.ModellingBusConnector.Reporter.Error("Something went wrong when converting to JSON. %s", err)
-		return true
+		return fmt.Errorf("converting to JSON: %w", err)
 	}
 
 	// No issues found
-	return false
+	return nil
 }
 
 /*
@@ -208,112 +473,215 @@ func (b *TModellingBusArtefactConnector) foundJSONIssue(err error) bool {
  * Posting artefacts
  */
 
-// Preparing for posting artefacts
+// Preparing for posting artefacts.
+// To let listeners resynchronise with the artefact, the poster should also listen for requests to repost its
+// state (see ListenForJSONArtefactStateRequests).
 func (b *TModellingBusArtefactConnector) PrepareForPosting(ArtefactID string) {
 	// Set the artefact ID
 	b.ArtefactID = ArtefactID
 }
 
 // Posting raw artefact state
-func (b *TModellingBusArtefactConnector) PostRawArtefactState(topicPath, localFilePath string) {
+func (b *TModellingBusArtefactConnector) TryPostRawArtefactState(topicPath, localFilePath string) error {
 	// Post the raw artefact state
-	b.ModellingBusConnector.postFile(b.rawArtefactsTopicPath(b.ArtefactID), localFilePath, generics.GetTimestamp())
+	return b.ModellingBusConnector.postFile(b.rawArtefactsTopicPath(b.ArtefactID), localFilePath, generics.GetTimestamp())
 }
 
-// Posting JSON artefact state
-func (b *TModellingBusArtefactConnector) PostJSONArtefactState(stateJSON []byte, err error) {
+// Posting JSON artefact state, which starts a new undo history
+func (b *TModellingBusArtefactConnector) TryPostJSONArtefactState(stateJSON []byte, err error) error {
 	// Check for errors
-	if b.foundJSONIssue(err) {
-		return
+	if err := b.foundJSONIssue(err); err != nil {
+		return err
 	}
 
-	// Post the JSON artefact state
-	b.CurrentTimestamp = generics.GetTimestamp()
-	b.CurrentContent = stateJSON
-	b.UpdatedContent = stateJSON
-	b.ConsideredContent = stateJSON
-	b.ModellingBusConnector.postJSONAsFile(b.jsonArtefactsStateTopicPath(b.ArtefactID), b.CurrentContent, b.CurrentTimestamp)
+	// Forget the undo history, as it concerns an earlier state
+	b.clearUndoHistory()
 
-	// Mark that the state has been communicated
-	b.stateCommunicated = true
+	return b.postJSONArtefactState(stateJSON)
 }
 
-// Posting JSON artefact update
-func (b *TModellingBusArtefactConnector) PostJSONArtefactUpdate(updatedStateJSON []byte, err error) {
+// Posting JSON artefact update, which can be undone
+func (b *TModellingBusArtefactConnector) TryPostJSONArtefactUpdate(updatedStateJSON []byte, err error) error {
 	// Check for errors
-	if b.foundJSONIssue(err) {
-		return
-	}
-
-	// Ensure the state has been communicated
-	if !b.stateCommunicated {
-		b.PostJSONArtefactState(updatedStateJSON, err)
+	if err := b.foundJSONIssue(err); err != nil {
+		return err
 	}
 
-	// Post the JSON artefact update
-	b.UpdatedContent = updatedStateJSON
-	b.ConsideredContent = updatedStateJSON
-	b.postJSONDelta(b.jsonArtefactsUpdateTopicPath(b.ArtefactID), b.CurrentContent, b.UpdatedContent, err)
+	return b.recordUndoStep(artefactUpdatePathElement, func() error {
+		return b.postJSONArtefactUpdate(updatedStateJSON)
+	})
 }
 
-// Posting JSON considered artefact
-func (b *TModellingBusArtefactConnector) PostJSONArtefactConsidering(consideringStateJSON []byte, err error) {
+// Posting JSON considered artefact, which can be undone
+func (b *TModellingBusArtefactConnector) TryPostJSONArtefactConsidering(consideringStateJSON []byte, err error) error {
 	// Check for errors
-	if b.foundJSONIssue(err) {
-		return
+	if err := b.foundJSONIssue(err); err != nil {
+		return err
 	}
 
-	// Ensure the state has been communicated
-	if !b.stateCommunicated {
-		b.PostJSONArtefactState(b.CurrentContent, err)
-	}
+	return b.recordUndoStep(artefactConsideringPathElement, func() error {
+		return b.postJSONArtefactConsidering(consideringStateJSON)
+	})
+}
 
-	// Post the JSON considered artefact
-	b.ConsideredContent = consideringStateJSON
+// Posting raw artefact state, reporting potential errors
+func (b *TModellingBusArtefactConnector) PostRawArtefactState(topicPath, localFilePath string) {
+	b.ModellingBusConnector.reportError("Something went wrong posting the artefact state.", b.TryPostRawArtefactState(topicPath, localFilePath))
+}
 
-	// Post the JSON considered artefact
-	b.postJSONDelta(b.jsonArtefactsConsideringTopicPath(b.ArtefactID), b.UpdatedContent, b.ConsideredContent, err)
+// Posting JSON artefact state, reporting potential errors
+func (b *TModellingBusArtefactConnector) PostJSONArtefactState(stateJSON []byte, err error) {
+	b.ModellingBusConnector.reportError("Something went wrong posting the artefact state.", b.TryPostJSONArtefactState(stateJSON, err))
+}
+
+// Posting JSON artefact update, reporting potential errors
+func (b *TModellingBusArtefactConnector) PostJSONArtefactUpdate(updatedStateJSON []byte, err error) {
+	b.ModellingBusConnector.reportError("Something went wrong posting the artefact update.", b.TryPostJSONArtefactUpdate(updatedStateJSON, err))
+}
+
+// Posting JSON considered artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) PostJSONArtefactConsidering(consideringStateJSON []byte, err error) {
+	b.ModellingBusConnector.reportError("Something went wrong posting the considered artefact.", b.TryPostJSONArtefactConsidering(consideringStateJSON, err))
 }
 
 /*
  * Listening to artefact related postings
  */
 
+// Serialise the handling of postings by the listeners of the artefact connector. Each subscription calls its
+// handler from a goroutine of its own, while the listeners of an artefact connector share the artefact content.
+// As a result, postings of different kinds may be handled in another order than they were posted.
+func (b *TModellingBusArtefactConnector) serialised(postingHandler func([]byte, string) error) func([]byte, string) error {
+	return func(json []byte, timestamp string) error {
+		b.listenerMutex.Lock()
+		defer b.listenerMutex.Unlock()
+
+		return postingHandler(json, timestamp)
+	}
+}
+
 // Listening for raw artefact state postings
-func (b *TModellingBusArtefactConnector) ListenForRawArtefactStatePostings(agentID, artefactID string, postingHandler func(string)) {
+func (b *TModellingBusArtefactConnector) ListenForRawArtefactStatePostings(agentID, artefactID string, postingHandler func(string)) *Subscription {
 	// Listen for raw artefact state postings
-	b.ModellingBusConnector.listenForFilePostings(agentID, b.rawArtefactsTopicPath(artefactID), generics.JSONFileName, func(localFilePath, _ string) {
+	return b.ModellingBusConnector.listenForFilePostings(agentID, b.rawArtefactsTopicPath(artefactID), generics.JSONFileName, func(localFilePath, _ string) error {
 		postingHandler(localFilePath)
+		return nil
 	})
 }
 
-// Listening for JSON artefact state postings
-func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStatePostings(agentID, artefactID string, handler func()) {
+// Listening for JSON artefact state postings. States that are not newer than the current state are ignored.
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStatePostings(agentID, artefactID string, handler func()) *Subscription {
 	// Listen for JSON artefact state postings
-	b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsStateTopicPath(artefactID), func(json []byte, currentTimestamp string) {
+	return b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsStateTopicPath(artefactID), b.serialised(func(json []byte, currentTimestamp string) error {
+		if b.isOutdatedState(currentTimestamp) {
+			return nil
+		}
+
 		b.updateCurrentJSONArtefact(json, currentTimestamp)
 		handler()
-	})
+		return nil
+	}))
 }
 
 // Listening for JSON artefact update postings
-func (b *TModellingBusArtefactConnector) ListenForJSONArtefactUpdatePostings(agentID, artefactID string, handler func()) {
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactUpdatePostings(agentID, artefactID string, handler func()) *Subscription {
 	// Listen for JSON artefact update postings
-	b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsUpdateTopicPath(artefactID), func(json []byte, _ string) {
-		if b.updateUpdatedJSONArtefact(json) {
-			handler()
+	return b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsUpdateTopicPath(artefactID), b.serialised(func(json []byte, _ string) error {
+		err := b.updateUpdatedJSONArtefact(agentID, artefactID, json)
+		if err != nil {
+			// Resynchronise, unless the delta has already been applied
+			if err := b.resyncJSONArtefact(agentID, artefactID, json, err); err != nil {
+				return b.deltaError(err)
+			}
 		}
-	})
+
+		handler()
+		return nil
+	}))
 }
 
 // Listening for JSON considered artefact postings
-func (b *TModellingBusArtefactConnector) ListenForJSONArtefactConsideringPostings(agentID, artefactID string, handler func()) {
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactConsideringPostings(agentID, artefactID string, handler func()) *Subscription {
 	// Listen for JSON considered artefact postings
-	b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsConsideringTopicPath(artefactID), func(json []byte, _ string) {
-		if b.updateConsideringJSONArtefact(json) {
-			handler()
+	return b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsConsideringTopicPath(artefactID), b.serialised(func(json []byte, _ string) error {
+		err := b.updateConsideringJSONArtefact(json)
+		if err != nil {
+			// Resynchronise, unless the delta has already been applied
+			if err := b.resyncJSONArtefact(agentID, artefactID, json, err); err != nil {
+				return b.deltaError(err)
+			}
 		}
-	})
+
+		handler()
+		return nil
+	}))
+}
+
+// Listening for raw artefact state postings, delivered by means of a posting stream
+func (b *TModellingBusArtefactConnector) ListenForRawArtefactStatePostingsStream(agentID, artefactID string, options TStreamOptions) *TPostingStream[TFilePosting] {
+	stream := createPostingStream[TFilePosting](options)
+
+	// Listen for raw artefact state postings
+	return stream.attach(b.ModellingBusConnector.listenForFilePostings(agentID, b.rawArtefactsTopicPath(artefactID), generics.JSONFileName, func(localFilePath, timestamp string) error {
+		stream.deliver(TFilePosting{LocalFilePath: localFilePath, Timestamp: timestamp})
+		return nil
+	}))
+}
+
+// Listening for JSON artefact state postings, delivering the resulting current content by means of a posting stream.
+// States that are not newer than the current state are ignored.
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactStatePostingsStream(agentID, artefactID string, options TStreamOptions) *TPostingStream[TJSONPosting] {
+	stream := createPostingStream[TJSONPosting](options)
+
+	// Listen for JSON artefact state postings
+	return stream.attach(b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsStateTopicPath(artefactID), b.serialised(func(json []byte, currentTimestamp string) error {
+		if b.isOutdatedState(currentTimestamp) {
+			return nil
+		}
+
+		b.updateCurrentJSONArtefact(json, currentTimestamp)
+		stream.deliver(TJSONPosting{JSON: b.CurrentContent, Timestamp: currentTimestamp})
+		return nil
+	})))
+}
+
+// Listening for JSON artefact update postings, delivering the resulting updated content by means of a posting stream
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactUpdatePostingsStream(agentID, artefactID string, options TStreamOptions) *TPostingStream[TJSONPosting] {
+	stream := createPostingStream[TJSONPosting](options)
+
+	// Listen for JSON artefact update postings
+	return stream.attach(b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsUpdateTopicPath(artefactID), b.serialised(func(json []byte, timestamp string) error {
+		err := b.updateUpdatedJSONArtefact(agentID, artefactID, json)
+		if err != nil {
+			// Resynchronise, unless the delta has already been applied
+			if err := b.resyncJSONArtefact(agentID, artefactID, json, err); err != nil {
+				return b.deltaError(err)
+			}
+		}
+
+		stream.deliver(TJSONPosting{JSON: b.UpdatedContent, Timestamp: timestamp})
+		return nil
+	})))
+}
+
+// Listening for JSON considered artefact postings, delivering the resulting considered content by means of a
+// posting stream
+func (b *TModellingBusArtefactConnector) ListenForJSONArtefactConsideringPostingsStream(agentID, artefactID string, options TStreamOptions) *TPostingStream[TJSONPosting] {
+	stream := createPostingStream[TJSONPosting](options)
+
+	// Listen for JSON considered artefact postings
+	return stream.attach(b.ModellingBusConnector.listenForJSONFilePostings(agentID, b.jsonArtefactsConsideringTopicPath(artefactID), b.serialised(func(json []byte, timestamp string) error {
+		err := b.updateConsideringJSONArtefact(json)
+		if err != nil {
+			// Resynchronise, unless the delta has already been applied
+			if err := b.resyncJSONArtefact(agentID, artefactID, json, err); err != nil {
+				return b.deltaError(err)
+			}
+		}
+
+		stream.deliver(TJSONPosting{JSON: b.ConsideredContent, Timestamp: timestamp})
+		return nil
+	})))
 }
 
 /*
@@ -321,33 +689,86 @@ func (b *TModellingBusArtefactConnector) ListenForJSONArtefactConsideringPosting
  */
 
 // Getting raw artefact state
-func (b *TModellingBusArtefactConnector) GetRawArtefactState(agentID, topicPath, localFileName string) (string, string) {
+func (b *TModellingBusArtefactConnector) TryGetRawArtefactState(agentID, topicPath, localFileName string) (string, string, error) {
 	// Get the raw artefact state
 	return b.ModellingBusConnector.getFileFromPosting(agentID, topicPath, localFileName)
 }
 
 // Getting JSON artefact state
-func (b *TModellingBusArtefactConnector) GetJSONArtefactState(agentID, artefactID string) {
+func (b *TModellingBusArtefactConnector) TryGetJSONArtefactState(agentID, artefactID string) error {
+	// Get the JSON artefact state
+	json, currentTimestamp, err := b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsStateTopicPath(artefactID))
+	if err != nil {
+		return err
+	}
+
 	// Update the current JSON artefact state
-	b.updateCurrentJSONArtefact(b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsStateTopicPath(artefactID)))
+	b.updateCurrentJSONArtefact(json, currentTimestamp)
+
+	return nil
 }
 
-// Getting JSON artefact update
-func (b *TModellingBusArtefactConnector) GetJSONArtefactUpdate(agentID, artefactID string) {
+// Getting JSON artefact update.
+// When no update has been posted (yet), the updated state is the current state, which is not an error.
+func (b *TModellingBusArtefactConnector) TryGetJSONArtefactUpdate(agentID, artefactID string) error {
+	// Get the JSON artefact state
+	if err := b.TryGetJSONArtefactState(agentID, artefactID); err != nil {
+		return err
+	}
+
 	// Get the JSON artefact update
-	b.GetJSONArtefactState(agentID, artefactID)
+	json, _, err := b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsUpdateTopicPath(artefactID))
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
 
 	// Update the updated JSON artefact state
-	b.updateUpdatedJSONArtefact(b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsUpdateTopicPath(artefactID)))
+	return b.updateUpdatedJSONArtefact(agentID, artefactID, json)
 }
 
-// Getting JSON artefact considering
-func (b *TModellingBusArtefactConnector) GetJSONArtefactConsidering(agentID, artefactID string) {
+// Getting JSON artefact considering.
+// When nothing is being considered, the considered state is the updated state, which is not an error.
+func (b *TModellingBusArtefactConnector) TryGetJSONArtefactConsidering(agentID, artefactID string) error {
 	// Get the JSON artefact update
-	b.GetJSONArtefactUpdate(agentID, artefactID)
+	if err := b.TryGetJSONArtefactUpdate(agentID, artefactID); err != nil {
+		return err
+	}
+
+	// Get the JSON artefact considering
+	json, _, err := b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsConsideringTopicPath(artefactID))
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
 
 	// Update the considered JSON artefact state
-	b.updateConsideringJSONArtefact(b.ModellingBusConnector.getJSON(agentID, b.jsonArtefactsConsideringTopicPath(artefactID)))
+	return b.updateConsideringJSONArtefact(json)
+}
+
+// Getting raw artefact state, reporting potential errors
+func (b *TModellingBusArtefactConnector) GetRawArtefactState(agentID, topicPath, localFileName string) (string, string) {
+	localFilePath, timestamp, err := b.TryGetRawArtefactState(agentID, topicPath, localFileName)
+	b.ModellingBusConnector.reportError("Something went wrong retrieving the artefact state.", err)
+
+	return localFilePath, timestamp
+}
+
+// Getting JSON artefact state, reporting potential errors
+func (b *TModellingBusArtefactConnector) GetJSONArtefactState(agentID, artefactID string) {
+	b.ModellingBusConnector.reportError("Something went wrong retrieving the artefact state.", b.TryGetJSONArtefactState(agentID, artefactID))
+}
+
+// Getting JSON artefact update, reporting potential errors
+func (b *TModellingBusArtefactConnector) GetJSONArtefactUpdate(agentID, artefactID string) {
+	b.reportDeltaError(b.TryGetJSONArtefactUpdate(agentID, artefactID))
+}
+
+// Getting JSON artefact considering, reporting potential errors
+func (b *TModellingBusArtefactConnector) GetJSONArtefactConsidering(agentID, artefactID string) {
+	b.reportDeltaError(b.TryGetJSONArtefactConsidering(agentID, artefactID))
 }
 
 /*
@@ -355,15 +776,27 @@ func (b *TModellingBusArtefactConnector) GetJSONArtefactConsidering(agentID, art
  */
 
 // Deleting raw artefact
-func (b *TModellingBusArtefactConnector) DeleteRawArtefact(artefactID string) {
+func (b *TModellingBusArtefactConnector) TryDeleteRawArtefact(artefactID string) error {
 	// Delete the raw artefact
-	b.ModellingBusConnector.deletePosting(b.rawArtefactsTopicPath(artefactID))
+	return b.ModellingBusConnector.deletePosting(b.rawArtefactsTopicPath(artefactID))
 }
 
 // Deleting JSON artefact
+func (b *TModellingBusArtefactConnector) TryDeleteJSONArtefact(artefactID string) error {
+	// Delete the JSON artefact, including its version history
+	return errors.Join(
+		b.ModellingBusConnector.deleteArchivedPostings(b.jsonArtefactsHistoryTopicPath(artefactID)),
+		b.ModellingBusConnector.deletePosting(b.jsonArtefactsTopicPath(artefactID)))
+}
+
+// Deleting raw artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) DeleteRawArtefact(artefactID string) {
+	b.ModellingBusConnector.reportError("Something went wrong deleting the artefact.", b.TryDeleteRawArtefact(artefactID))
+}
+
+// Deleting JSON artefact, reporting potential errors
 func (b *TModellingBusArtefactConnector) DeleteJSONArtefact(artefactID string) {
-	// Delete the JSON artefact
-	b.ModellingBusConnector.deletePosting(b.jsonArtefactsTopicPath(artefactID))
+	b.ModellingBusConnector.reportError("Something went wrong deleting the artefact.", b.TryDeleteJSONArtefact(artefactID))
 }
 
 /*
@@ -380,7 +813,16 @@ func CreateModellingBusArtefactConnector(ModellingBusConnector TModellingBusConn
 	ModellingBusArtefactConnector.UpdatedContent = []byte{}
 	ModellingBusArtefactConnector.ConsideredContent = []byte{}
 	ModellingBusArtefactConnector.CurrentTimestamp = generics.GetTimestamp()
+	ModellingBusArtefactConnector.ChainedUpdates = ModellingBusConnector.configData.GetValue("artefacts", "chained_updates").BoolWithDefault(false)
+	ModellingBusArtefactConnector.CompactionPolicy = compactionPolicyFromConfig(ModellingBusConnector.configData)
 	ModellingBusArtefactConnector.stateCommunicated = false
+	ModellingBusArtefactConnector.listenerMutex = &sync.Mutex{}
+	ModellingBusArtefactConnector.sharedMutex = &sync.Mutex{}
+	ModellingBusArtefactConnector.sharedVersions = map[string]json.RawMessage{}
+	ModellingBusArtefactConnector.sharedVersionOrder = []string{}
+	ModellingBusArtefactConnector.maxUndoSteps = ModellingBusConnector.configData.GetValue("artefacts", "undo_steps").IntWithDefault(defaultUndoSteps)
+	ModellingBusArtefactConnector.clearUndoHistory()
+	ModellingBusArtefactConnector.maxSharedVersions = ModellingBusConnector.configData.GetValue("artefacts", "shared_versions").IntWithDefault(defaultSharedVersions)
 
 	// Return the created modelling bus artefact connector
 	return ModellingBusArtefactConnector
@@ -0,0 +1,175 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Artefacts (tests)
+ *
+ * These tests post JSON artefacts, and listen for their states and updates.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+// Get the updated content of an artefact connector, while its listeners may be handling postings
+func updatedTestContent(b *TModellingBusArtefactConnector) json.RawMessage {
+	b.listenerMutex.Lock()
+	defer b.listenerMutex.Unlock()
+
+	return b.UpdatedContent
+}
+
+func TestListenersKeepUpdatesReceivedBeforeTheirState(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+	listener := createTestArtefactConnector(t, "listener", "")
+
+	updates := make(chan struct{}, 10)
+	stateSubscription := listener.ListenForJSONArtefactStatePostings("poster", "model", func() {})
+	defer stateSubscription.Unsubscribe()
+	updateSubscription := listener.ListenForJSONArtefactUpdatePostings("poster", "model", func() { updates <- struct{}{} })
+	defer updateSubscription.Unsubscribe()
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+
+	select {
+	case <-updates:
+	case <-time.After(testTimeout):
+		t.Fatal("the update did not arrive in time")
+	}
+
+	// The state may still be handled after the update, which should not undo the update
+	for range 20 {
+		checkTestJSON(t, "updated", updatedTestContent(&listener), `{"a":2}`)
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailedUpdatesLeaveTheUpdatedContentAlone(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":2}`), nil); err != nil {
+		t.Fatalf("posting the update: %s", err)
+	}
+
+	// An update that cannot be posted, should not be taken as the updated content
+	repositoryConnector := poster.ModellingBusConnector.modellingBusRepositoryConnector
+	backend := &tTestFailingRepositoryBackend{tRepositoryBackend: repositoryConnector.backend}
+	repositoryConnector.backend = backend
+	backend.failWith(errors.New("storing is not allowed"))
+	if err := poster.TryPostJSONArtefactUpdate([]byte(`{"a":3}`), nil); err == nil {
+		t.Fatal("expected posting the update to fail")
+	}
+	checkTestJSON(t, "updated", poster.UpdatedContent, `{"a":2}`)
+	checkTestJSON(t, "considered", poster.ConsideredContent, `{"a":2}`)
+}
+
+func TestChainedUpdatesAreNumberedPerState(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model", "[artefacts]\nchained_updates = true")
+
+	// Each state starts a new chain of updates
+	for _, content := range []string{`{"a":0}`, `{"a":10}`} {
+		if err := poster.TryPostJSONArtefactState([]byte(content), nil); err != nil {
+			t.Fatalf("posting the state: %s", err)
+		}
+		for _, update := range []string{`{"a":1}`, `{"a":2}`} {
+			if err := poster.TryPostJSONArtefactUpdate([]byte(update), nil); err != nil {
+				t.Fatalf("posting the update: %s", err)
+			}
+		}
+	}
+
+	versions, err := poster.TryListJSONArtefactVersions("poster", "model")
+	if err != nil {
+		t.Fatalf("listing the versions: %s", err)
+	}
+
+	sequences := []int{}
+	for _, version := range versions {
+		sequences = append(sequences, version.Sequence)
+	}
+	if !slices.Equal(sequences, []int{0, 1, 2, 0, 1, 2}) {
+		t.Errorf("expected the updates of each state to be numbered from 1, got %v", sequences)
+	}
+}
+
+func TestChainedUpdatesFillGapsFromTheHistory(t *testing.T) {
+	// Without switching on the history explicitly
+	poster := createTestArtefactConnector(t, "poster", "model", "[artefacts]\nchained_updates = true")
+	listener := createTestArtefactConnector(t, "listener", "")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":0}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	for _, update := range []string{`{"a":1}`, `{"a":1,"b":2}`, `{"a":1,"b":2,"c":3}`} {
+		if err := poster.TryPostJSONArtefactUpdate([]byte(update), nil); err != nil {
+			t.Fatalf("posting the update: %s", err)
+		}
+	}
+
+	// Only the last update is on the bus, so the ones before it are taken from the history
+	if err := listener.TryGetJSONArtefactUpdate("poster", "model"); err != nil {
+		t.Fatalf("getting the update: %s", err)
+	}
+	checkTestJSON(t, "updated", listener.UpdatedContent, `{"a":1,"b":2,"c":3}`)
+	if listener.updateSequence != 3 {
+		t.Errorf("expected to be at update 3, got %d", listener.updateSequence)
+	}
+}
+
+func TestChainedUpdatesDetectGaps(t *testing.T) {
+	poster := createTestArtefactConnector(t, "poster", "model", "[artefacts]\nchained_updates = true")
+	listener := createTestArtefactConnector(t, "listener", "")
+
+	if err := poster.TryPostJSONArtefactState([]byte(`{"a":0}`), nil); err != nil {
+		t.Fatalf("posting the state: %s", err)
+	}
+	if err := listener.TryGetJSONArtefactState("poster", "model"); err != nil {
+		t.Fatalf("getting the state: %s", err)
+	}
+
+	for _, test := range []struct {
+		name  string
+		delta TJSONDelta
+	}{
+		{"missing from the history", TJSONDelta{Sequence: 3, PreviousTimestamp: "2"}},
+		{"following another update", TJSONDelta{Sequence: 1, PreviousTimestamp: "0"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.delta.Timestamp = generics.GetTimestamp()
+			test.delta.CurrentTimestamp = listener.CurrentTimestamp
+			test.delta.Operations = json.RawMessage(`[]`)
+			deltaJSON, err := json.Marshal(test.delta)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := listener.updateUpdatedJSONArtefact("poster", "model", deltaJSON); !errors.Is(err, ErrUpdateGap) {
+				t.Errorf("expected a gap, got %v", err)
+			}
+			if listener.updateSequence != 0 {
+				t.Errorf("expected to remain at the state, got update %d", listener.updateSequence)
+			}
+		})
+	}
+}
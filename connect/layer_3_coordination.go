@@ -8,7 +8,7 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 16.10.2026
  *
  */
 
@@ -39,30 +39,57 @@ func (b *TModellingBusConnector) coordinationTopicPath(coordinationID string) st
  * Posting coordination messages
  */
 
+func (b *TModellingBusConnector) TryPostCoordination(coordinationID string, json []byte) error {
+	return b.postJSONAsStreamed(b.coordinationTopicPath(coordinationID), json, generics.GetTimestamp())
+}
+
 func (b *TModellingBusConnector) PostCoordination(coordinationID string, json []byte) {
-	b.postJSONAsStreamed(b.coordinationTopicPath(coordinationID), json, generics.GetTimestamp())
+	b.reportError("Something went wrong posting the coordination message.", b.TryPostCoordination(coordinationID, json))
 }
 
 /*
  * Listening to coordination related postings
  */
 
-func (b *TModellingBusConnector) ListenForCoordinationPostings(agentID, coordinationID string, postingHandler func([]byte, string)) {
-	b.listenForStreamedPostings(agentID, b.coordinationTopicPath(coordinationID), postingHandler)
+func (b *TModellingBusConnector) ListenForCoordinationPostings(agentID, coordinationID string, postingHandler func([]byte, string)) *Subscription {
+	return b.listenForStreamedPostings(agentID, b.coordinationTopicPath(coordinationID), func(json []byte, timestamp string) error {
+		postingHandler(json, timestamp)
+		return nil
+	})
+}
+
+func (b *TModellingBusConnector) ListenForCoordinationPostingsStream(agentID, coordinationID string, options TStreamOptions) *TPostingStream[TJSONPosting] {
+	stream := createPostingStream[TJSONPosting](options)
+
+	return stream.attach(b.listenForStreamedPostings(agentID, b.coordinationTopicPath(coordinationID), func(json []byte, timestamp string) error {
+		stream.deliver(TJSONPosting{JSON: json, Timestamp: timestamp})
+		return nil
+	}))
 }
 
 /*
  * Retrieving coordination messages
  */
 
-func (b *TModellingBusConnector) GetCoordination(agentID, coordinationID string) ([]byte, string) {
+func (b *TModellingBusConnector) TryGetCoordination(agentID, coordinationID string) ([]byte, string, error) {
 	return b.getStreamed(agentID, b.coordinationTopicPath(coordinationID))
 }
 
+func (b *TModellingBusConnector) GetCoordination(agentID, coordinationID string) ([]byte, string) {
+	json, timestamp, err := b.TryGetCoordination(agentID, coordinationID)
+	b.reportError("Something went wrong retrieving the coordination message.", err)
+
+	return json, timestamp
+}
+
 /*
  * Deleting coordination messages
  */
 
+func (b *TModellingBusConnector) TryDeleteCoordination(coordinationID string) error {
+	return b.deletePosting(b.coordinationTopicPath(coordinationID))
+}
+
 func (b *TModellingBusConnector) DeleteCoordination(coordinationID string) {
-	b.deletePosting(b.coordinationTopicPath(coordinationID))
+	b.reportError("Something went wrong deleting the coordination message.", b.TryDeleteCoordination(coordinationID))
 }
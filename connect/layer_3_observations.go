@@ -8,7 +8,7 @@
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 05.12.2025
+ * Version of: 16.10.2026
  *
  */
 
@@ -53,66 +53,143 @@ func (b *TModellingBusConnector) streamedObservationsTopicPath(observationID str
  * Posting observations
  */
 
+func (b *TModellingBusConnector) TryPostRawObservation(observationID, localFilePath string) error {
+	return b.postFile(b.rawObservationsTopicPath(observationID), localFilePath, generics.GetTimestamp())
+}
+
+func (b *TModellingBusConnector) TryPostJSONObservation(observationID string, json []byte) error {
+	return b.postJSONAsFile(b.jsonObservationsTopicPath(observationID), json, generics.GetTimestamp())
+}
+
+func (b *TModellingBusConnector) TryPostStreamedObservation(observationID string, json []byte) error {
+	return b.postJSONAsStreamed(b.streamedObservationsTopicPath(observationID), json, generics.GetTimestamp())
+}
+
 func (b *TModellingBusConnector) PostRawObservation(observationID, localFilePath string) {
-	b.postFile(b.rawObservationsTopicPath(observationID), localFilePath, generics.GetTimestamp())
+	b.reportError("Something went wrong posting the observation.", b.TryPostRawObservation(observationID, localFilePath))
 }
 
 func (b *TModellingBusConnector) PostJSONObservation(observationID string, json []byte) {
-	b.postJSONAsFile(b.jsonObservationsTopicPath(observationID), json, generics.GetTimestamp())
+	b.reportError("Something went wrong posting the observation.", b.TryPostJSONObservation(observationID, json))
 }
 
 func (b *TModellingBusConnector) PostStreamedObservation(observationID string, json []byte) {
-	b.postJSONAsStreamed(b.streamedObservationsTopicPath(observationID), json, generics.GetTimestamp())
+	b.reportError("Something went wrong posting the observation.", b.TryPostStreamedObservation(observationID, json))
 }
 
 /*
  * Listening to observations related postings
  */
 
-func (b *TModellingBusConnector) ListenForRawObservationPostings(agentID, observationID string, postingHandler func(string)) {
-	b.listenForFilePostings(agentID, b.rawObservationsTopicPath(observationID), generics.JSONFileName, func(localFilePath, _ string) {
+func (b *TModellingBusConnector) ListenForRawObservationPostings(agentID, observationID string, postingHandler func(string)) *Subscription {
+	return b.listenForFilePostings(agentID, b.rawObservationsTopicPath(observationID), generics.JSONFileName, func(localFilePath, _ string) error {
 		postingHandler(localFilePath)
+		return nil
 	})
 }
 
-func (b *TModellingBusConnector) ListenForJSONObservationPostings(agentID, observationID string, postingHandler func([]byte, string)) {
-	b.listenForFilePostings(agentID, b.jsonObservationsTopicPath(observationID), generics.JSONFileName, func(localFilePath, timestamp string) {
-		postingHandler(b.getJSONFromTemporaryFile(localFilePath, timestamp))
+func (b *TModellingBusConnector) ListenForJSONObservationPostings(agentID, observationID string, postingHandler func([]byte, string)) *Subscription {
+	return b.listenForJSONFilePostings(agentID, b.jsonObservationsTopicPath(observationID), func(json []byte, timestamp string) error {
+		postingHandler(json, timestamp)
+		return nil
 	})
 }
 
-func (b *TModellingBusConnector) ListenForStreamedObservationPostings(agentID, observationID string, postingHandler func([]byte, string)) {
-	b.listenForStreamedPostings(agentID, b.streamedObservationsTopicPath(observationID), postingHandler)
+func (b *TModellingBusConnector) ListenForStreamedObservationPostings(agentID, observationID string, postingHandler func([]byte, string)) *Subscription {
+	return b.listenForStreamedPostings(agentID, b.streamedObservationsTopicPath(observationID), func(json []byte, timestamp string) error {
+		postingHandler(json, timestamp)
+		return nil
+	})
+}
+
+func (b *TModellingBusConnector) ListenForRawObservationPostingsStream(agentID, observationID string, options TStreamOptions) *TPostingStream[TFilePosting] {
+	stream := createPostingStream[TFilePosting](options)
+
+	return stream.attach(b.listenForFilePostings(agentID, b.rawObservationsTopicPath(observationID), generics.JSONFileName, func(localFilePath, timestamp string) error {
+		stream.deliver(TFilePosting{LocalFilePath: localFilePath, Timestamp: timestamp})
+		return nil
+	}))
+}
+
+func (b *TModellingBusConnector) ListenForJSONObservationPostingsStream(agentID, observationID string, options TStreamOptions) *TPostingStream[TJSONPosting] {
+	stream := createPostingStream[TJSONPosting](options)
+
+	return stream.attach(b.listenForJSONFilePostings(agentID, b.jsonObservationsTopicPath(observationID), func(json []byte, timestamp string) error {
+		stream.deliver(TJSONPosting{JSON: json, Timestamp: timestamp})
+		return nil
+	}))
+}
+
+func (b *TModellingBusConnector) ListenForStreamedObservationPostingsStream(agentID, observationID string, options TStreamOptions) *TPostingStream[TJSONPosting] {
+	stream := createPostingStream[TJSONPosting](options)
+
+	return stream.attach(b.listenForStreamedPostings(agentID, b.streamedObservationsTopicPath(observationID), func(json []byte, timestamp string) error {
+		stream.deliver(TJSONPosting{JSON: json, Timestamp: timestamp})
+		return nil
+	}))
 }
 
 /*
  * Retrieving observations
  */
 
-func (b *TModellingBusConnector) GetRawObservation(agentID, observationID, localFileName string) (string, string) {
+func (b *TModellingBusConnector) TryGetRawObservation(agentID, observationID, localFileName string) (string, string, error) {
 	return b.getFileFromPosting(agentID, b.rawObservationsTopicPath(observationID), localFileName)
 }
 
-func (b *TModellingBusConnector) GetJSONObservation(agentID, observationID string) ([]byte, string) {
+func (b *TModellingBusConnector) TryGetJSONObservation(agentID, observationID string) ([]byte, string, error) {
 	return b.getJSON(agentID, b.jsonObservationsTopicPath(observationID))
 }
 
-func (b *TModellingBusConnector) GetStreamedObservation(agentID, observationID string) ([]byte, string) {
+func (b *TModellingBusConnector) TryGetStreamedObservation(agentID, observationID string) ([]byte, string, error) {
 	return b.getStreamed(agentID, b.streamedObservationsTopicPath(observationID))
 }
 
+func (b *TModellingBusConnector) GetRawObservation(agentID, observationID, localFileName string) (string, string) {
+	localFilePath, timestamp, err := b.TryGetRawObservation(agentID, observationID, localFileName)
+	b.reportError("Something went wrong retrieving the observation.", err)
+
+	return localFilePath, timestamp
+}
+
+func (b *TModellingBusConnector) GetJSONObservation(agentID, observationID string) ([]byte, string) {
+	json, timestamp, err := b.TryGetJSONObservation(agentID, observationID)
+	b.reportError("Something went wrong retrieving the observation.", err)
+
+	return json, timestamp
+}
+
+func (b *TModellingBusConnector) GetStreamedObservation(agentID, observationID string) ([]byte, string) {
+	json, timestamp, err := b.TryGetStreamedObservation(agentID, observationID)
+	b.reportError("Something went wrong retrieving the observation.", err)
+
+	return json, timestamp
+}
+
 /*
  * Deleting observations
  */
 
+func (b *TModellingBusConnector) TryDeleteRawObservation(observationID string) error {
+	return b.deletePosting(b.rawObservationsTopicPath(observationID))
+}
+
+func (b *TModellingBusConnector) TryDeleteJSONObservation(observationID string) error {
+	return b.deletePosting(b.jsonObservationsTopicPath(observationID))
+}
+
+func (b *TModellingBusConnector) TryDeleteStreamedObservation(observationID string) error {
+	return b.deletePosting(b.streamedObservationsTopicPath(observationID))
+}
+
 func (b *TModellingBusConnector) DeleteRawObservation(observationID string) {
-	b.deletePosting(b.rawObservationsTopicPath(observationID))
+	b.reportError("Something went wrong deleting the observation.", b.TryDeleteRawObservation(observationID))
 }
 
 func (b *TModellingBusConnector) DeleteJSONObservation(observationID string) {
-	b.deletePosting(b.jsonObservationsTopicPath(observationID))
+	b.reportError("Something went wrong deleting the observation.", b.TryDeleteJSONObservation(observationID))
 }
 
 func (b *TModellingBusConnector) DeleteStreamedObservation(observationID string) {
-	b.deletePosting(b.streamedObservationsTopicPath(observationID))
+	b.reportError("Something went wrong deleting the observation.", b.TryDeleteStreamedObservation(observationID))
 }
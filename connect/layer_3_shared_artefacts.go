@@ -0,0 +1,435 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Shared Artefacts
+ *
+ * This component enables several agents to edit the same JSON artefact, e.g. for collaborative modelling.
+ * Each agent posts its updates of a shared artefact as deltas against a known base version, on its own topics,
+ * while listening to the updates of all other agents. The current version, and the content of recent versions,
+ * are kept by each agent.
+ * An update based on the current version is simply applied. An update based on an older version is the result of
+ * a concurrent edit, and is merged with the current content (see generics.JSONMerge3). Changes to different parts
+ * of the artefact are merged automatically. Changes to the same part are conflicts, which are resolved in favour
+ * of the latest version, and reported to the conflict handler (see SetConflictHandler).
+ * Each version has the same content for all agents. So, when the merged content differs from the content of both
+ * merged versions, it becomes a new version, which the merging agent posts as a delta against the version it
+ * received. As all agents resolve conflicts in the same way, merging the same versions results in the same
+ * content, and the agents converge to the same content once their updates have been received.
+ * The number of recent versions kept can be set by means of the "shared_versions" key in the "artefacts" section
+ * of the config file.
+ * As received updates are applied while the agent may be posting updates itself, the current content, version,
+ * and recent versions are guarded by a mutex. It is held from determining a delta until the version it results in
+ * has been set, but not while calling the handlers.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	sharedArtefactPathElement = "shared" // Shared artefact path element
+
+	defaultSharedVersions = 64 // Default number of recent versions of a shared artefact to be kept
+)
+
+/*
+ * Defining shared artefacts
+ */
+
+type (
+	// A conflict between concurrent updates of a shared JSON artefact, where "ours" refers to the current content,
+	// and "theirs" to the content according to the update
+	TSharedArtefactConflict struct {
+		AgentID string // The agent whose update conflicted with the current content
+		generics.TJSONConflict
+	}
+
+	// A shared JSON artefact state, as posted by one of the agents sharing it
+	tJSONSharedState struct {
+		Version string          `json:"version"` // The version of the state
+		Content json.RawMessage `json:"content"` // The content of the state
+	}
+
+	// A delta of a shared JSON artefact, against a base version
+	tJSONSharedDelta struct {
+		Author      string          `json:"author"`       // The agent posting the delta
+		BaseVersion string          `json:"base version"` // The version the delta is based on
+		Version     string          `json:"version"`      // The version resulting from the delta
+		Operations  json.RawMessage `json:"operations"`   // The JSON delta operations
+	}
+)
+
+// Defining topic paths for shared json artefact states
+func (b *TModellingBusArtefactConnector) jsonSharedArtefactsStateTopicPath(artefactID string) string {
+	return b.jsonArtefactsTopicPath(artefactID) +
+		"/" + sharedArtefactPathElement +
+		"/" + artefactStatePathElement
+}
+
+// Defining topic paths for shared json artefact updates
+func (b *TModellingBusArtefactConnector) jsonSharedArtefactsUpdateTopicPath(artefactID string) string {
+	return b.jsonArtefactsTopicPath(artefactID) +
+		"/" + sharedArtefactPathElement +
+		"/" + artefactUpdatePathElement
+}
+
+/*
+ * Keeping versions
+ */
+
+// Create a new version ID. The agent ID makes it unique, and breaks ties between agents in a fixed way.
+func (b *TModellingBusArtefactConnector) newSharedVersion() string {
+	return generics.GetTimestamp() + "-" + b.ModellingBusConnector.agentID
+}
+
+// Keep the content of a version, forgetting the oldest versions when needed
+func (b *TModellingBusArtefactConnector) keepSharedVersion(version string, content json.RawMessage) {
+	if _, known := b.sharedVersions[version]; !known {
+		b.sharedVersionOrder = append(b.sharedVersionOrder, version)
+	}
+	b.sharedVersions[version] = content
+
+	for len(b.sharedVersionOrder) > b.maxSharedVersions {
+		delete(b.sharedVersions, b.sharedVersionOrder[0])
+		b.sharedVersionOrder = b.sharedVersionOrder[1:]
+	}
+}
+
+// Set the current content and version of the shared artefact
+func (b *TModellingBusArtefactConnector) setSharedJSONArtefact(content json.RawMessage, version string) {
+	b.CurrentContent = content
+	b.UpdatedContent = content
+	b.ConsideredContent = content
+	b.CurrentTimestamp = version
+	b.keepSharedVersion(version, content)
+}
+
+/*
+ * Merging concurrent updates
+ */
+
+// Merge the concurrent changes from a base version to our and their content.
+// Conflicting changes are resolved in favour of the latest version.
+func (b *TModellingBusArtefactConnector) mergeSharedJSON(agentID string, baseJSON, oursJSON, theirsJSON []byte, oursIsLatest bool) (json.RawMessage, []TSharedArtefactConflict, error) {
+	// Merge, keeping the changes of the latest version in case of conflicts
+	latestJSON, otherJSON := oursJSON, theirsJSON
+	if !oursIsLatest {
+		latestJSON, otherJSON = theirsJSON, oursJSON
+	}
+
+	mergedJSON, jsonConflicts, err := generics.JSONMerge3(baseJSON, latestJSON, otherJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("merging concurrent updates: %w", err)
+	}
+
+	// Report the conflicts from our point of view
+	conflicts := []TSharedArtefactConflict{}
+	for _, conflict := range jsonConflicts {
+		if !oursIsLatest {
+			conflict.Ours, conflict.Theirs = conflict.Theirs, conflict.Ours
+		}
+
+		conflicts = append(conflicts, TSharedArtefactConflict{AgentID: agentID, TJSONConflict: conflict})
+	}
+
+	return mergedJSON, conflicts, nil
+}
+
+// Applying a received delta of a shared JSON artefact, resulting in the conflicts with the current content
+func (b *TModellingBusArtefactConnector) applySharedJSONDelta(deltaJSON []byte) ([]TSharedArtefactConflict, error) {
+	// Unmarshal the delta
+	delta := tJSONSharedDelta{}
+	if err := json.Unmarshal(deltaJSON, &delta); err != nil {
+		return nil, fmt.Errorf("unJSONing the received shared delta: %w", err)
+	}
+
+	// Our own deltas, and the ones we already know, have already been applied
+	if _, known := b.sharedVersions[delta.Version]; known || delta.Author == b.ModellingBusConnector.agentID {
+		return nil, fmt.Errorf("version %s: %w", delta.Version, errDeltaAlreadyApplied)
+	}
+
+	// Without the base version, we can only continue from the latest version
+	oursIsLatest := generics.CompareTimestamps(b.CurrentTimestamp, delta.Version) > 0
+	baseJSON, known := b.sharedVersions[delta.BaseVersion]
+	if !known {
+		// Older versions have already been incorporated in the current version
+		if oursIsLatest {
+			return nil, fmt.Errorf("version %s precedes the current version %s: %w", delta.Version, b.CurrentTimestamp, errDeltaAlreadyApplied)
+		}
+
+		return b.adoptSharedJSONArtefactState(delta.Author)
+	}
+
+	// Determine their content
+	theirsJSON, err := generics.JSONApplyPatch(baseJSON, delta.Operations)
+	if err != nil {
+		return nil, fmt.Errorf("applying patch: %w", err)
+	}
+	b.keepSharedVersion(delta.Version, theirsJSON)
+
+	// Updates based on the current version can simply be applied
+	if delta.BaseVersion == b.CurrentTimestamp {
+		b.setSharedJSONArtefact(theirsJSON, delta.Version)
+
+		return nil, nil
+	}
+
+	// Concurrent updates need to be merged, resulting in the latest of both versions
+	mergedJSON, conflicts, err := b.mergeSharedJSON(delta.Author, baseJSON, b.CurrentContent, theirsJSON, oursIsLatest)
+	if err != nil {
+		return nil, err
+	}
+
+	return conflicts, b.settleSharedMerge(delta.Version, theirsJSON, mergedJSON, oursIsLatest)
+}
+
+// Settle on a version for merged content, such that each version has the same content for all agents.
+// When the merged content is the same as ours or theirs, the agents can settle on that version. Otherwise, the
+// merged content becomes a new version, which is posted as a delta against their version.
+func (b *TModellingBusArtefactConnector) settleSharedMerge(theirVersion string, theirsJSON, mergedJSON json.RawMessage, oursIsLatest bool) error {
+	// Compare the merged content to ours and theirs
+	oursDelta, err := b.diffJSON(b.CurrentContent, mergedJSON)
+	if err != nil {
+		return err
+	}
+	theirsDelta, err := b.diffJSON(theirsJSON, mergedJSON)
+	if err != nil {
+		return err
+	}
+	isOurs := deltaOperationsCount(oursDelta) == 0
+	isTheirs := deltaOperationsCount(theirsDelta) == 0
+
+	switch {
+	case isOurs && (!isTheirs || oursIsLatest):
+		// We already have the merged content
+		return nil
+
+	case isTheirs:
+		// They already have the merged content
+		b.setSharedJSONArtefact(theirsJSON, theirVersion)
+		return nil
+	}
+
+	// The merge has been applied regardless, so failing to post it is only reported
+	version := b.newSharedVersion()
+	b.setSharedJSONArtefact(mergedJSON, version)
+	err = b.postSharedJSONDelta(theirVersion, version, theirsDelta)
+	if err == nil {
+		err = b.postSharedJSONArtefactState()
+	}
+	b.ModellingBusConnector.reportError("Something went wrong posting the merged shared artefact.", err)
+
+	return nil
+}
+
+// Continuing from the state as posted by a given agent, replacing the current content as a whole.
+// This results in a conflict for the whole artefact, unless the content is the same.
+func (b *TModellingBusArtefactConnector) adoptSharedJSONArtefactState(agentID string) ([]TSharedArtefactConflict, error) {
+	// Get the state
+	state, err := b.getSharedJSONArtefactState(agentID, b.ArtefactID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state may already have been incorporated in the current version
+	if generics.CompareTimestamps(state.Version, b.CurrentTimestamp) <= 0 {
+		return nil, fmt.Errorf("version %s precedes the current version %s: %w", state.Version, b.CurrentTimestamp, errDeltaAlreadyApplied)
+	}
+
+	// Determine the conflict, if any
+	differences, err := b.diffJSON(b.CurrentContent, state.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []TSharedArtefactConflict{}
+	if deltaOperationsCount(differences) > 0 {
+		conflicts = append(conflicts, TSharedArtefactConflict{
+			AgentID:       agentID,
+			TJSONConflict: generics.TJSONConflict{Ours: b.CurrentContent, Theirs: state.Content},
+		})
+	}
+
+	// Continue from the state
+	b.setSharedJSONArtefact(state.Content, state.Version)
+
+	return conflicts, nil
+}
+
+// Get the state of a shared JSON artefact, as posted by a given agent
+func (b *TModellingBusArtefactConnector) getSharedJSONArtefactState(agentID, artefactID string) (tJSONSharedState, error) {
+	state := tJSONSharedState{}
+
+	// Get the state
+	stateJSON, _, err := b.ModellingBusConnector.getJSON(agentID, b.jsonSharedArtefactsStateTopicPath(artefactID))
+	if err != nil {
+		return state, err
+	}
+
+	// Unmarshal the state
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return state, fmt.Errorf("unJSONing the shared state: %w", err)
+	}
+
+	return state, nil
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+// Set the handler to be called when concurrent updates of a shared JSON artefact conflict.
+// The conflicts have been resolved in favour of the latest version, which is reflected in the content of the
+// artefact connector. The handler may post a shared update to resolve them differently.
+func (b *TModellingBusArtefactConnector) SetConflictHandler(conflictHandler func(conflicts []TSharedArtefactConflict)) {
+	b.sharedMutex.Lock()
+	defer b.sharedMutex.Unlock()
+
+	b.conflictHandler = conflictHandler
+}
+
+// Start sharing a JSON artefact, with a given initial state
+func (b *TModellingBusArtefactConnector) TryShareJSONArtefact(artefactID string, stateJSON []byte, err error) error {
+	// Check for errors
+	if err := b.foundJSONIssue(err); err != nil {
+		return err
+	}
+
+	b.sharedMutex.Lock()
+	defer b.sharedMutex.Unlock()
+
+	// Set the artefact ID, and the initial version
+	b.ArtefactID = artefactID
+	b.setSharedJSONArtefact(stateJSON, b.newSharedVersion())
+
+	// Post the state, for agents joining later
+	return b.postSharedJSONArtefactState()
+}
+
+// Join in sharing a JSON artefact, starting from the state as posted by a given agent sharing it
+func (b *TModellingBusArtefactConnector) TryJoinSharedJSONArtefact(agentID, artefactID string) error {
+	// Get the state
+	state, err := b.getSharedJSONArtefactState(agentID, artefactID)
+	if err != nil {
+		return err
+	}
+
+	b.sharedMutex.Lock()
+	defer b.sharedMutex.Unlock()
+
+	// Set the artefact ID, and the version of the state
+	b.ArtefactID = artefactID
+	b.setSharedJSONArtefact(state.Content, state.Version)
+
+	return nil
+}
+
+// Posting the state of the shared JSON artefact, for agents joining later
+func (b *TModellingBusArtefactConnector) postSharedJSONArtefactState() error {
+	stateJSON, err := json.Marshal(tJSONSharedState{Version: b.CurrentTimestamp, Content: b.CurrentContent})
+	if err != nil {
+		return fmt.Errorf("JSONing the shared state: %w", err)
+	}
+
+	return b.ModellingBusConnector.postJSONAsFile(b.jsonSharedArtefactsStateTopicPath(b.ArtefactID), stateJSON, generics.GetTimestamp())
+}
+
+// Posting a delta of the shared JSON artefact, from a base version to a new version
+func (b *TModellingBusArtefactConnector) postSharedJSONDelta(baseVersion, version string, deltaOperationsJSON json.RawMessage) error {
+	// Create the delta object
+	delta := tJSONSharedDelta{
+		Author:      b.ModellingBusConnector.agentID,
+		BaseVersion: baseVersion,
+		Version:     version,
+		Operations:  deltaOperationsJSON,
+	}
+
+	// Convert the delta to JSON
+	deltaJSON, err := json.Marshal(delta)
+	if err != nil {
+		return fmt.Errorf("JSONing the shared delta: %w", err)
+	}
+
+	// Post the delta JSON
+	return b.ModellingBusConnector.postJSONAsFile(b.jsonSharedArtefactsUpdateTopicPath(b.ArtefactID), deltaJSON, generics.GetTimestamp())
+}
+
+// Posting an update of the shared JSON artefact, as a delta against the current version
+func (b *TModellingBusArtefactConnector) TryPostSharedJSONArtefactUpdate(updatedStateJSON []byte, err error) error {
+	// Check for errors
+	if err := b.foundJSONIssue(err); err != nil {
+		return err
+	}
+
+	// Received updates must wait until we have moved to the new version
+	b.sharedMutex.Lock()
+	defer b.sharedMutex.Unlock()
+
+	// Determine the delta
+	deltaOperationsJSON, err := b.diffJSON(b.CurrentContent, updatedStateJSON)
+	if err != nil {
+		return err
+	}
+
+	// Post the delta
+	version := b.newSharedVersion()
+	if err := b.postSharedJSONDelta(b.CurrentTimestamp, version, deltaOperationsJSON); err != nil {
+		return err
+	}
+
+	// Move to the new version, and post the new state for agents joining later
+	b.setSharedJSONArtefact(updatedStateJSON, version)
+
+	return b.postSharedJSONArtefactState()
+}
+
+// Listening for updates of the shared JSON artefact by the other agents sharing it
+func (b *TModellingBusArtefactConnector) ListenForSharedJSONArtefactUpdates(handler func()) *Subscription {
+	// Listen for shared updates from all agents
+	return b.ModellingBusConnector.listenForJSONFilePostings("+", b.jsonSharedArtefactsUpdateTopicPath(b.ArtefactID), b.serialised(func(json []byte, _ string) error {
+		b.sharedMutex.Lock()
+		conflicts, err := b.applySharedJSONDelta(json)
+		conflictHandler := b.conflictHandler
+		b.sharedMutex.Unlock()
+
+		if err != nil {
+			return b.deltaError(err)
+		}
+
+		// Report the conflicts, if any
+		if len(conflicts) > 0 && conflictHandler != nil {
+			conflictHandler(conflicts)
+		}
+
+		handler()
+		return nil
+	}))
+}
+
+// Start sharing a JSON artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) ShareJSONArtefact(artefactID string, stateJSON []byte, err error) {
+	b.ModellingBusConnector.reportError("Something went wrong sharing the artefact.", b.TryShareJSONArtefact(artefactID, stateJSON, err))
+}
+
+// Join in sharing a JSON artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) JoinSharedJSONArtefact(agentID, artefactID string) {
+	b.ModellingBusConnector.reportError("Something went wrong joining the shared artefact.", b.TryJoinSharedJSONArtefact(agentID, artefactID))
+}
+
+// Posting an update of the shared JSON artefact, reporting potential errors
+func (b *TModellingBusArtefactConnector) PostSharedJSONArtefactUpdate(updatedStateJSON []byte, err error) {
+	b.ModellingBusConnector.reportError("Something went wrong posting the shared artefact update.", b.TryPostSharedJSONArtefactUpdate(updatedStateJSON, err))
+}
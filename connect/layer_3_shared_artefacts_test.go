@@ -0,0 +1,102 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Shared Artefacts (tests)
+ *
+ * These tests let several agents edit the same JSON artefact, while receiving each other's updates.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Get the content and version of a shared artefact, as known to an artefact connector
+func sharedTestContent(b *TModellingBusArtefactConnector) (json.RawMessage, string) {
+	b.sharedMutex.Lock()
+	defer b.sharedMutex.Unlock()
+
+	return b.CurrentContent, b.CurrentTimestamp
+}
+
+// Check whether two JSON values are the same, regardless of their formatting
+func sameTestJSON(aJSON, bJSON json.RawMessage) bool {
+	var a, b any
+	if json.Unmarshal(aJSON, &a) != nil || json.Unmarshal(bJSON, &b) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func TestSharedArtefactConcurrentUpdates(t *testing.T) {
+	alice := CreateModellingBusArtefactConnector(createTestConnector(t, "alice"), "v1")
+	bob := CreateModellingBusArtefactConnector(createTestConnector(t, "bob"), "v1")
+
+	if err := alice.TryShareJSONArtefact("model", []byte(`{"alice":0,"bob":0}`), nil); err != nil {
+		t.Fatalf("sharing: %s", err)
+	}
+	if err := bob.TryJoinSharedJSONArtefact("alice", "model"); err != nil {
+		t.Fatalf("joining: %s", err)
+	}
+
+	for _, agent := range []*TModellingBusArtefactConnector{&alice, &bob} {
+		subscription := agent.ListenForSharedJSONArtefactUpdates(func() {})
+		defer subscription.Unsubscribe()
+	}
+
+	// Both agents post updates of their own part, while receiving the updates of the other
+	wait := sync.WaitGroup{}
+	for _, agent := range []*TModellingBusArtefactConnector{&alice, &bob} {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+
+			for update := 1; update <= 5; update++ {
+				content, _ := sharedTestContent(agent)
+
+				state := map[string]int{}
+				if err := json.Unmarshal(content, &state); err != nil {
+					t.Errorf("unJSONing %s: %s", content, err)
+					return
+				}
+				state[agent.ModellingBusConnector.agentID] = update
+
+				updatedJSON, err := json.Marshal(state)
+				if err := agent.TryPostSharedJSONArtefactUpdate(updatedJSON, err); err != nil {
+					t.Errorf("posting: %s", err)
+					return
+				}
+			}
+		}()
+	}
+	wait.Wait()
+
+	// Eventually, both agents are at the same version, with the same content. As each agent posts its updated
+	// content as a whole, an update received while it was preparing its own update may be undone by it, so the
+	// content itself may differ from the last updates.
+	deadline := time.Now().Add(testTimeout)
+	for {
+		aliceContent, aliceVersion := sharedTestContent(&alice)
+		bobContent, bobVersion := sharedTestContent(&bob)
+		if aliceVersion == bobVersion && sameTestJSON(aliceContent, bobContent) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("no convergence: alice has %s (%s), bob has %s (%s)", aliceContent, aliceVersion, bobContent, bobVersion)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,419 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Transactions
+ *
+ * This component provides transactions between agents, on top of coordination messages.
+ * A coordinator sends a request to a specific agent, which acknowledges it. Later on, the agent reports the
+ * completion or failure of the request, which the coordinator acknowledges in turn.
+ * All messages of a transaction share a correlation ID. Requests and reports are not persistent: once read by
+ * the agent they are meant for, they are removed from the bus by that agent. The same holds for acknowledgements.
+ * When a request is not acknowledged in time, or not reported on before its deadline, the transaction times out.
+ * The time to wait for acknowledgements can be set by means of the "acknowledge_timeout" key (in seconds) in the
+ * "transactions" section of the config file.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+const (
+	transactionsCoordinationElement = "transactions" // Coordination ID element for transactions
+
+	transactionRequestsElement               = "requests"                // Coordination ID element for requests
+	transactionAcknowledgementsElement       = "acknowledgements"        // Coordination ID element for acknowledgements of requests
+	transactionReportsElement                = "reports"                 // Coordination ID element for reports
+	transactionReportAcknowledgementsElement = "report-acknowledgements" // Coordination ID element for acknowledgements of reports
+
+	defaultAcknowledgeTimeout = 30 // Default time to wait for acknowledgements, in seconds
+)
+
+/*
+ * Defining transactions
+ */
+
+type (
+	// The status of a transaction
+	TTransactionStatus string
+
+	// A request from a coordinator to an agent
+	TTransactionRequest struct {
+		CorrelationID string          `json:"correlation id"`     // The ID shared by all messages of the transaction
+		Coordinator   string          `json:"coordinator"`        // The agent making the request
+		Recipient     string          `json:"recipient"`          // The agent the request is meant for
+		RequestType   string          `json:"request type"`       // The type of request
+		Payload       json.RawMessage `json:"payload,omitempty"`  // The details of the request
+		Deadline      time.Time       `json:"deadline,omitempty"` // The moment by which the request should be reported on, if any
+	}
+
+	// A report on a request, which is also used to inform the coordinator about the progress of a transaction
+	TTransactionReport struct {
+		CorrelationID string             `json:"correlation id"`    // The ID shared by all messages of the transaction
+		Agent         string             `json:"agent"`             // The agent the request was meant for
+		Status        TTransactionStatus `json:"status"`            // The status of the transaction
+		Payload       json.RawMessage    `json:"payload,omitempty"` // The results of the request
+		Reason        string             `json:"reason,omitempty"`  // The reason for failing, or timing out
+	}
+
+	// An acknowledgement of a request or report
+	tTransactionAcknowledgement struct {
+		CorrelationID string `json:"correlation id"` // The ID shared by all messages of the transaction
+		Agent         string `json:"agent"`          // The agent acknowledging
+	}
+
+	// A transaction in progress, at the side of the coordinator
+	tTransaction struct {
+		mutex        sync.Mutex // Ensures the transaction is finished only once
+		handlerMutex sync.Mutex // Ensures the handler is called one report at a time, in order
+
+		acknowledged bool // Whether the request has been acknowledged
+		finished     bool // Whether the transaction has been finished
+
+		subscriptions []*Subscription // The subscriptions for the acknowledgement and report
+		timers        []*time.Timer   // The timers for the acknowledgement and the deadline
+	}
+)
+
+const (
+	TransactionAcknowledged TTransactionStatus = "acknowledged" // The request has been acknowledged
+	TransactionCompleted    TTransactionStatus = "completed"    // The request has been completed
+	TransactionFailed       TTransactionStatus = "failed"       // The request has failed
+	TransactionTimedOut     TTransactionStatus = "timed out"    // The request was not acknowledged, or reported on, in time
+)
+
+// Defining coordination IDs for the messages of transactions, where the agent is the one the message is meant for
+func (b *TModellingBusConnector) transactionCoordinationID(element, agentID, correlationID string) string {
+	return transactionsCoordinationElement +
+		"/" + element +
+		"/" + agentID +
+		"/" + correlationID
+}
+
+// Defining coordination IDs for requests of a given type, meant for a given agent
+func (b *TModellingBusConnector) transactionRequestCoordinationID(agentID, requestType, correlationID string) string {
+	return b.transactionCoordinationID(transactionRequestsElement, agentID, requestType+"/"+correlationID)
+}
+
+// Determine the time to wait for acknowledgements
+func (b *TModellingBusConnector) acknowledgeTimeout() time.Duration {
+	return time.Duration(b.configData.GetValue("transactions", "acknowledge_timeout").IntWithDefault(defaultAcknowledgeTimeout)) * time.Second
+}
+
+/*
+ * Posting and consuming transaction messages
+ */
+
+// Posting a transaction message
+func (b *TModellingBusConnector) postTransactionMessage(coordinationID string, message any) error {
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("JSONing the transaction message: %w", err)
+	}
+
+	return b.TryPostCoordination(coordinationID, messageJSON)
+}
+
+// Consuming a transaction message posted by a given agent, by removing it from the bus.
+// As transaction messages are streamed postings, they only reside on the event bus.
+func (b *TModellingBusConnector) consumeTransactionMessage(agentID, coordinationID string) error {
+	return b.deleteStreamedPostingOf(agentID, b.coordinationTopicPath(coordinationID))
+}
+
+// Acknowledging a request or report
+func (b *TModellingBusConnector) acknowledgeTransactionMessage(element, agentID, correlationID string) error {
+	return b.postTransactionMessage(b.transactionCoordinationID(element, agentID, correlationID), tTransactionAcknowledgement{
+		CorrelationID: correlationID,
+		Agent:         b.agentID,
+	})
+}
+
+/*
+ * Running transactions
+ */
+
+// Finish a transaction, unless already finished, by no longer waiting for its messages.
+// The result is whether the transaction was finished by this call.
+func (t *tTransaction) finish() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.finished {
+		return false
+	}
+	t.finished = true
+
+	// Stop waiting
+	for _, timer := range t.timers {
+		timer.Stop()
+	}
+	for _, subscription := range t.subscriptions {
+		subscription.Unsubscribe()
+	}
+
+	return true
+}
+
+// Mark a transaction as acknowledged, unless already finished.
+// The result is whether the transaction was marked by this call.
+func (t *tTransaction) acknowledge() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.finished || t.acknowledged {
+		return false
+	}
+	t.acknowledged = true
+
+	return true
+}
+
+// Check whether a transaction has been acknowledged
+func (t *tTransaction) hasBeenAcknowledged() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.acknowledged
+}
+
+// Time out a transaction, unless finished in the meantime
+func (b *TModellingBusConnector) timeOutTransaction(t *tTransaction, request TTransactionRequest, reason string, handler func(TTransactionReport)) {
+	// Only when not finished in the meantime
+	if !t.finish() {
+		return
+	}
+
+	// The request is no longer relevant
+	b.reportError("Something went wrong withdrawing the request.",
+		b.consumeTransactionMessage(b.agentID, b.transactionRequestCoordinationID(request.Recipient, request.RequestType, request.CorrelationID)))
+
+	// Report the time out
+	t.handlerMutex.Lock()
+	defer t.handlerMutex.Unlock()
+
+	handler(TTransactionReport{
+		CorrelationID: request.CorrelationID,
+		Agent:         request.Recipient,
+		Status:        TransactionTimedOut,
+		Reason:        reason,
+	})
+}
+
+/*
+ *
+ * Externally visible functionality
+ *
+ */
+
+/*
+ * Coordinating
+ */
+
+// Requesting a given agent to handle a request of a given type, resulting in the correlation ID of the transaction.
+// The handler is called once the request has been acknowledged, and once it has been completed, has failed, or
+// has timed out. When the timeout is 0, the request has no deadline.
+func (b *TModellingBusConnector) TryRequest(agentID, requestType string, payload []byte, timeout time.Duration, handler func(report TTransactionReport)) (string, error) {
+	// Create the request
+	request := TTransactionRequest{
+		CorrelationID: generics.GetTimestamp() + "-" + b.agentID,
+		Coordinator:   b.agentID,
+		Recipient:     agentID,
+		RequestType:   requestType,
+		Payload:       payload,
+	}
+	if timeout > 0 {
+		request.Deadline = time.Now().Add(timeout)
+	}
+
+	transaction := &tTransaction{}
+
+	// Listen for the acknowledgement
+	acknowledgementID := b.transactionCoordinationID(transactionAcknowledgementsElement, b.agentID, request.CorrelationID)
+	transaction.subscriptions = append(transaction.subscriptions, b.listenForStreamedPostings(agentID, b.coordinationTopicPath(acknowledgementID), func(_ []byte, _ string) error {
+		if err := b.consumeTransactionMessage(agentID, acknowledgementID); err != nil {
+			return err
+		}
+
+		transaction.handlerMutex.Lock()
+		defer transaction.handlerMutex.Unlock()
+
+		if transaction.acknowledge() {
+			handler(TTransactionReport{CorrelationID: request.CorrelationID, Agent: agentID, Status: TransactionAcknowledged})
+		}
+
+		return nil
+	}))
+
+	// Listen for the report
+	reportID := b.transactionCoordinationID(transactionReportsElement, b.agentID, request.CorrelationID)
+	transaction.subscriptions = append(transaction.subscriptions, b.listenForStreamedPostings(agentID, b.coordinationTopicPath(reportID), func(reportJSON []byte, _ string) error {
+		if err := b.consumeTransactionMessage(agentID, reportID); err != nil {
+			return err
+		}
+
+		report := TTransactionReport{}
+		if err := json.Unmarshal(reportJSON, &report); err != nil {
+			return fmt.Errorf("unJSONing the report: %w", err)
+		}
+
+		// Acknowledge the report
+		if err := b.acknowledgeTransactionMessage(transactionReportAcknowledgementsElement, agentID, request.CorrelationID); err != nil {
+			return err
+		}
+
+		transaction.handlerMutex.Lock()
+		defer transaction.handlerMutex.Unlock()
+
+		// A report implies the request has been acknowledged
+		if transaction.acknowledge() {
+			handler(TTransactionReport{CorrelationID: request.CorrelationID, Agent: agentID, Status: TransactionAcknowledged})
+		}
+
+		if transaction.finish() {
+			handler(report)
+		}
+
+		return nil
+	}))
+
+	// Time out when the request is not acknowledged, or reported on, in time
+	transaction.mutex.Lock()
+	transaction.timers = append(transaction.timers, time.AfterFunc(b.acknowledgeTimeout(), func() {
+		if !transaction.hasBeenAcknowledged() {
+			b.timeOutTransaction(transaction, request, "the request was not acknowledged", handler)
+		}
+	}))
+	if timeout > 0 {
+		transaction.timers = append(transaction.timers, time.AfterFunc(timeout, func() {
+			b.timeOutTransaction(transaction, request, "the request was not reported on before its deadline", handler)
+		}))
+	}
+	transaction.mutex.Unlock()
+
+	// Post the request
+	if err := b.postTransactionMessage(b.transactionRequestCoordinationID(agentID, requestType, request.CorrelationID), request); err != nil {
+		transaction.finish()
+		return "", err
+	}
+
+	return request.CorrelationID, nil
+}
+
+// Requesting a given agent to handle a request of a given type, reporting potential errors
+func (b *TModellingBusConnector) Request(agentID, requestType string, payload []byte, timeout time.Duration, handler func(report TTransactionReport)) string {
+	correlationID, err := b.TryRequest(agentID, requestType, payload, timeout, handler)
+	b.reportError("Something went wrong posting the request.", err)
+
+	return correlationID
+}
+
+/*
+ * Handling requests
+ */
+
+// Listening for requests of a given type meant for us, including the ones already pending.
+// Requests are acknowledged, and removed from the bus, before calling the handler. Requests past their deadline
+// are removed from the bus without calling the handler. The handler should eventually report on the request,
+// by means of ReportCompletion or ReportFailure.
+// The coordinator of a request is the agent that posted it, so requests claiming to be from another agent are
+// removed from the bus without calling the handler.
+func (b *TModellingBusConnector) ListenForRequests(requestType string, handler func(request TTransactionRequest)) *Subscription {
+	// Listen for requests from all agents
+	return b.listenForPendingStreamedPostings("+", b.coordinationTopicPath(b.transactionRequestCoordinationID(b.agentID, requestType, "+")), func(coordinator string, requestJSON []byte, _ string) error {
+		request := TTransactionRequest{}
+		if err := json.Unmarshal(requestJSON, &request); err != nil {
+			return fmt.Errorf("unJSONing the request of agent %s: %w", coordinator, err)
+		}
+
+		// Consume the request, as posted by the coordinator
+		if err := b.consumeTransactionMessage(coordinator, b.transactionRequestCoordinationID(b.agentID, requestType, request.CorrelationID)); err != nil {
+			return err
+		}
+
+		// Requests claiming to be from another agent cannot be trusted
+		if request.Coordinator != coordinator {
+			return fmt.Errorf("request %s was posted by agent %s, while claiming to be from agent %s", request.CorrelationID, coordinator, request.Coordinator)
+		}
+
+		// Requests past their deadline are no longer relevant
+		if !request.Deadline.IsZero() && time.Now().After(request.Deadline) {
+			b.Reporter.Progress(generics.ProgressLevelDetailed, "Ignoring request %s of agent %s, as it is past its deadline.", request.CorrelationID, request.Coordinator)
+			return nil
+		}
+
+		// Acknowledge the request
+		if err := b.acknowledgeTransactionMessage(transactionAcknowledgementsElement, request.Coordinator, request.CorrelationID); err != nil {
+			return err
+		}
+
+		handler(request)
+		return nil
+	})
+}
+
+// Reporting on a request
+func (b *TModellingBusConnector) tryReport(request TTransactionRequest, report TTransactionReport) error {
+	// Listen for the acknowledgement of the report, and remove it from the bus once received
+	acknowledgementID := b.transactionCoordinationID(transactionReportAcknowledgementsElement, b.agentID, request.CorrelationID)
+	var acknowledgementTimer *time.Timer
+	var subscription *Subscription
+	subscription = b.listenForStreamedPostings(request.Coordinator, b.coordinationTopicPath(acknowledgementID), func(_ []byte, _ string) error {
+		acknowledgementTimer.Stop()
+
+		return errors.Join(
+			b.consumeTransactionMessage(request.Coordinator, acknowledgementID),
+			subscription.Unsubscribe())
+	})
+
+	// Stop waiting for the acknowledgement, when not received in time
+	acknowledgementTimer = time.AfterFunc(b.acknowledgeTimeout(), func() {
+		if !subscription.hasEnded() {
+			b.Reporter.Error("The report on request %s was not acknowledged by agent %s.", request.CorrelationID, request.Coordinator)
+			subscription.Unsubscribe()
+		}
+	})
+
+	// Post the report
+	report.CorrelationID = request.CorrelationID
+	report.Agent = b.agentID
+	if err := b.postTransactionMessage(b.transactionCoordinationID(transactionReportsElement, request.Coordinator, request.CorrelationID), report); err != nil {
+		acknowledgementTimer.Stop()
+		subscription.Unsubscribe()
+		return err
+	}
+
+	return nil
+}
+
+// Reporting the completion of a request, with its results
+func (b *TModellingBusConnector) TryReportCompletion(request TTransactionRequest, payload []byte) error {
+	return b.tryReport(request, TTransactionReport{Status: TransactionCompleted, Payload: payload})
+}
+
+// Reporting the failure of a request, with the reason for failing
+func (b *TModellingBusConnector) TryReportFailure(request TTransactionRequest, reason string) error {
+	return b.tryReport(request, TTransactionReport{Status: TransactionFailed, Reason: reason})
+}
+
+// Reporting the completion of a request, reporting potential errors
+func (b *TModellingBusConnector) ReportCompletion(request TTransactionRequest, payload []byte) {
+	b.reportError("Something went wrong reporting the completion of the request.", b.TryReportCompletion(request, payload))
+}
+
+// Reporting the failure of a request, reporting potential errors
+func (b *TModellingBusConnector) ReportFailure(request TTransactionRequest, reason string) {
+	b.reportError("Something went wrong reporting the failure of the request.", b.TryReportFailure(request, reason))
+}
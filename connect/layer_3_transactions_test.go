@@ -0,0 +1,134 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Connect
+ * Component: Layer 3 - Transactions (tests)
+ *
+ * These tests run transactions between a coordinator and an agent handling its requests.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package connect
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A repository backend for tests, which counts the paths being deleted
+type tTestDeletionCountingRepositoryBackend struct {
+	tRepositoryBackend // The repository backend doing the actual work
+
+	deletions atomic.Int32 // The number of paths deleted
+}
+
+// Delete a path, counting the deletion
+func (c *tTestDeletionCountingRepositoryBackend) deletePath(remotePath string) error {
+	c.deletions.Add(1)
+
+	return c.tRepositoryBackend.deletePath(remotePath)
+}
+
+// Receive the next transaction report, failing when it does not arrive in time
+func receiveTestReport(t *testing.T, reports chan TTransactionReport) TTransactionReport {
+	t.Helper()
+
+	select {
+	case report := <-reports:
+		return report
+	case <-time.After(testTimeout):
+		t.Fatal("no report arrived in time")
+	}
+
+	return TTransactionReport{}
+}
+
+func TestTransactionCompletes(t *testing.T) {
+	coordinator := createTestConnector(t, "coordinator")
+	worker := createTestConnector(t, "worker")
+
+	subscription := worker.ListenForRequests("sum", func(request TTransactionRequest) {
+		if err := worker.TryReportCompletion(request, []byte(`3`)); err != nil {
+			t.Errorf("reporting: %s", err)
+		}
+	})
+	defer subscription.Unsubscribe()
+
+	reports := make(chan TTransactionReport, 2)
+	if _, err := coordinator.TryRequest("worker", "sum", []byte(`[1,2]`), 0, func(report TTransactionReport) { reports <- report }); err != nil {
+		t.Fatalf("requesting: %s", err)
+	}
+
+	if report := receiveTestReport(t, reports); report.Status != TransactionAcknowledged {
+		t.Errorf("expected an acknowledgement, got %v", report)
+	}
+	if report := receiveTestReport(t, reports); report.Status != TransactionCompleted || string(report.Payload) != `3` {
+		t.Errorf("expected the completion, got %v", report)
+	}
+}
+
+func TestTransactionTimeOutOnlyWithdrawsRequest(t *testing.T) {
+	coordinator := createTestConnector(t, "coordinator")
+	backend := &tTestDeletionCountingRepositoryBackend{tRepositoryBackend: coordinator.modellingBusRepositoryConnector.backend}
+	coordinator.modellingBusRepositoryConnector.backend = backend
+
+	// Nobody handles the request, so it times out
+	reports := make(chan TTransactionReport, 1)
+	correlationID, err := coordinator.TryRequest("worker", "sum", []byte(`[1,2]`), 50*time.Millisecond, func(report TTransactionReport) { reports <- report })
+	if err != nil {
+		t.Fatalf("requesting: %s", err)
+	}
+	if report := receiveTestReport(t, reports); report.Status != TransactionTimedOut {
+		t.Fatalf("expected a time out, got %v", report)
+	}
+
+	// The request is withdrawn from the event bus, leaving the repository alone
+	if _, _, err := coordinator.TryGetCoordination("coordinator", coordinator.transactionRequestCoordinationID("worker", "sum", correlationID)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the request to be withdrawn, got %v", err)
+	}
+	if deletions := backend.deletions.Load(); deletions != 0 {
+		t.Errorf("deleted %d paths from the repository", deletions)
+	}
+}
+
+func TestTransactionRequestsClaimingOtherCoordinators(t *testing.T) {
+	impostor := createTestConnector(t, "impostor")
+	worker := createTestConnector(t, "worker")
+
+	handled := make(chan TTransactionRequest, 1)
+	rejections := make(chan error, 1)
+	subscription := worker.ListenForRequests("sum", func(request TTransactionRequest) { handled <- request })
+	subscription.SetErrorHandler(func(err error) { rejections <- err })
+	defer subscription.Unsubscribe()
+
+	// A request posted by one agent, claiming to be from another
+	request := TTransactionRequest{CorrelationID: "forged", Coordinator: "coordinator", Recipient: "worker", RequestType: "sum"}
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestID := impostor.transactionRequestCoordinationID("worker", "sum", request.CorrelationID)
+	if err := impostor.TryPostCoordination(requestID, requestJSON); err != nil {
+		t.Fatalf("posting: %s", err)
+	}
+
+	// Is rejected, and removed from the bus
+	select {
+	case <-rejections:
+	case request := <-handled:
+		t.Fatalf("handled %v", request)
+	case <-time.After(testTimeout):
+		t.Fatal("the request was not rejected in time")
+	}
+
+	if _, _, err := worker.TryGetCoordination("impostor", requestID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the request to be removed, got %v", err)
+	}
+}
@@ -15,7 +15,7 @@
  *
  * Author: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 27.11.2025
+ * Version of: 16.10.2026
  *
  */
 
@@ -37,8 +37,8 @@ type (
 	}
 )
 
-// Load the configuration file.
-func LoadConfig(filePath string, reporter *TReporter) *TConfigData {
+// Load the configuration file, returning potential errors.
+func TryLoadConfig(filePath string, reporter *TReporter) (*TConfigData, error) {
 	var (
 		err        error       //	Error return value
 		configData TConfigData // The read config data
@@ -47,11 +47,42 @@ func LoadConfig(filePath string, reporter *TReporter) *TConfigData {
 	reporter.Progress(1, "Reading config file: %s", filePath)
 	configData.configFile, err = ini.Load(filePath)
 
+	return &configData, err
+}
+
+// Load the configuration file.
+func LoadConfig(filePath string, reporter *TReporter) *TConfigData {
+	configData, err := TryLoadConfig(filePath, reporter)
+
 	if err != nil {
 		reporter.Panic("Failed to read config file. %s", err)
 	}
 
-	return &configData
+	return configData
+}
+
+// Load the configuration from data in ini format, as opposed to a file, returning potential errors.
+func TryLoadConfigFromData(data []byte) (*TConfigData, error) {
+	var (
+		err        error       //	Error return value
+		configData TConfigData // The read config data
+	)
+
+	configData.configFile, err = ini.Load(data)
+
+	return &configData, err
+}
+
+// Load the configuration from data in ini format, as opposed to a file. This is useful, for instance, when
+// creating connectors in tests.
+func LoadConfigFromData(data []byte, reporter *TReporter) *TConfigData {
+	configData, err := TryLoadConfigFromData(data)
+
+	if err != nil {
+		reporter.Panic("Failed to read config data. %s", err)
+	}
+
+	return configData
 }
 
 // Get the value from a given section and key from the read config data
@@ -9,17 +9,29 @@
  * This component gladly uses the functionality provided by "github.com/evanphx/json-patch" and "github.com/wI2L/jsondiff"
  * Nevertheless, having our own Diff and Patch functions makes the rest of the code less dependent on potential changes to
  * the latter two packages.
+ * Next to these, it provides merge patches compliant to the https://datatracker.ietf.org/doc/html/rfc7386 standard, as
+ * well as the composition of patches (collapsing successive operations on the same part of a document), and the
+ * inversion of patches, e.g. to undo them.
+ * Furthermore, it provides a three-way merge of JSONs. Objects are merged per key, so that map-keyed structures
+ * (such as the ones in a CDM model) are merged per entry. Arrays and other values are merged as a whole. Changes
+ * that cannot be merged are reported as conflicts, referring to the conflicting parts by means of JSON Pointers
+ * (see https://datatracker.ietf.org/doc/html/rfc6901).
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 29.11.2025
+ * Version of: 16.10.2026
  *
  */
 
 package generics
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/wI2L/jsondiff"
@@ -42,3 +54,376 @@ func JSONApplyPatch(sourceJSON, patchJSON []byte) (json.RawMessage, error) {
 
 	return patch.Apply(sourceJSON)
 }
+
+/*
+ * Merge patches
+ */
+
+func JSONCreateMergePatch(sourceJSON, targetJSON []byte) (json.RawMessage, error) {
+	return jsonpatch.CreateMergePatch(sourceJSON, targetJSON)
+}
+
+func JSONApplyMergePatch(sourceJSON, mergePatchJSON []byte) (json.RawMessage, error) {
+	return jsonpatch.MergePatch(sourceJSON, mergePatchJSON)
+}
+
+/*
+ * Composing and inverting patches
+ */
+
+// An operation of a patch
+type tJSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Decode the operations of a patch
+func decodeJSONPatch(patchJSON []byte) ([]tJSONPatchOperation, error) {
+	operations := []tJSONPatchOperation{}
+	if len(bytes.TrimSpace(patchJSON)) == 0 || string(bytes.TrimSpace(patchJSON)) == "null" {
+		return operations, nil
+	}
+
+	if err := json.Unmarshal(patchJSON, &operations); err != nil {
+		return nil, fmt.Errorf("decoding JSON patch: %w", err)
+	}
+
+	return operations, nil
+}
+
+// Check whether two JSON Pointers refer to overlapping parts of a JSON document.
+// As the empty JSON Pointer refers to the whole document, it overlaps with all others.
+func jsonPointersOverlap(pointer1, pointer2 string) bool {
+	return pointer1 == pointer2 ||
+		strings.HasPrefix(pointer1, pointer2+"/") ||
+		strings.HasPrefix(pointer2, pointer1+"/")
+}
+
+// Get the JSON Pointers involved in an operation
+func (o tJSONPatchOperation) pointers() []string {
+	if o.From != "" {
+		return []string{o.Path, o.From}
+	}
+
+	return []string{o.Path}
+}
+
+// Check whether two operations involve overlapping parts of a JSON document
+func (o tJSONPatchOperation) overlaps(other tJSONPatchOperation) bool {
+	for _, pointer := range o.pointers() {
+		for _, otherPointer := range other.pointers() {
+			if jsonPointersOverlap(pointer, otherPointer) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Check whether an operation may add or remove elements of an array, shifting the indexes of the elements after it
+func (o tJSONPatchOperation) shiftsIndexes() bool {
+	return o.Op == "add" || o.Op == "remove" || o.Op == "move" || o.Op == "copy"
+}
+
+// Get the parent of a JSON Pointer, together with the last element of the JSON Pointer
+func jsonPointerParent(pointer string) (string, string) {
+	separator := strings.LastIndex(pointer, "/")
+	if separator < 0 {
+		return "", pointer
+	}
+
+	return pointer[:separator], pointer[separator+1:]
+}
+
+// Check whether an element of a JSON Pointer may be an array index. Without the document, we cannot tell
+// whether it is an index or the key of an object member, so all numbers are taken to be indexes.
+func isJSONArrayIndex(element string) bool {
+	if element == "-" {
+		return true
+	}
+
+	for _, character := range element {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+
+	return element != ""
+}
+
+// Get the JSON Pointers to the arrays a JSON Pointer may point into, from the outermost to the innermost
+func jsonArrayParents(pointer string) []string {
+	arrayParents := []string{}
+
+	elements := strings.Split(pointer, "/")
+	for i := 1; i < len(elements); i++ {
+		if isJSONArrayIndex(elements[i]) {
+			arrayParents = append(arrayParents, strings.Join(elements[:i], "/"))
+		}
+	}
+
+	return arrayParents
+}
+
+// Check whether collapsed operations can take the place of an earlier operation, given the operations in between.
+// This is not the case when indexes in an array the later operation points into may have been shifted in between,
+// or when the collapsed operations would shift indexes used in between.
+func canCollapseAcross(later tJSONPatchOperation, collapsed, inBetween []tJSONPatchOperation) bool {
+	collapsedShiftsIndexes := false
+	for _, operation := range collapsed {
+		collapsedShiftsIndexes = collapsedShiftsIndexes || operation.shiftsIndexes()
+	}
+	laterParent, _ := jsonPointerParent(later.Path)
+
+	for _, arrayParent := range jsonArrayParents(later.Path) {
+		for _, operation := range inBetween {
+			for _, pointer := range operation.pointers() {
+				if !strings.HasPrefix(pointer, arrayParent+"/") {
+					continue
+				}
+
+				// An element was added to, or removed from, the array in between
+				if parent, _ := jsonPointerParent(pointer); parent == arrayParent && operation.shiftsIndexes() {
+					return false
+				}
+
+				// The collapsed operations would add or remove an element before the operation in between
+				if arrayParent == laterParent && collapsedShiftsIndexes {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// Collapse an earlier and a later operation on the same part of a JSON document, if possible.
+// The result are the operations replacing both, and whether they could be collapsed.
+func collapseJSONPatchOperations(earlier, later tJSONPatchOperation) ([]tJSONPatchOperation, bool) {
+	if earlier.Path != later.Path {
+		return nil, false
+	}
+
+	switch {
+	case earlier.Op == "add" && later.Op == "replace":
+		return []tJSONPatchOperation{{Op: "add", Path: later.Path, Value: later.Value}}, true
+
+	case earlier.Op == "replace" && (later.Op == "replace" || later.Op == "remove"):
+		return []tJSONPatchOperation{later}, true
+
+	case earlier.Op == "remove" && later.Op == "add" && !strings.HasSuffix(later.Path, "/-"):
+		return []tJSONPatchOperation{{Op: "replace", Path: later.Path, Value: later.Value}}, true
+
+	default:
+		return nil, false
+	}
+}
+
+// Compose two successive patches into one patch, having the same effect as applying them one after the other.
+// Operations of the second patch are collapsed with the last earlier operation involving an overlapping part of
+// the document, when that operation concerns exactly the same part, and the two can safely be combined. As
+// adding or removing array elements shifts the indexes of the elements after them, operations are never collapsed
+// across operations adding or removing elements of an array they point into.
+// All other operations are kept as they are.
+func JSONComposePatches(firstPatchJSON, secondPatchJSON []byte) (json.RawMessage, error) {
+	// Decode the patches
+	operations, err := decodeJSONPatch(firstPatchJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	laterOperations, err := decodeJSONPatch(secondPatchJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the operations of the second patch, collapsing them where possible
+	for _, later := range laterOperations {
+		// Find the last operation involving an overlapping part
+		last := len(operations) - 1
+		for last >= 0 && !operations[last].overlaps(later) {
+			last--
+		}
+
+		// Collapse with that operation, if possible
+		if last >= 0 {
+			if collapsed, ok := collapseJSONPatchOperations(operations[last], later); ok && canCollapseAcross(later, collapsed, operations[last+1:]) {
+				operations = append(operations[:last], append(collapsed, operations[last+1:]...)...)
+				continue
+			}
+		}
+
+		operations = append(operations, later)
+	}
+
+	return json.Marshal(operations)
+}
+
+// Invert a patch, given the source JSON it applies to, resulting in a patch that undoes its effect
+func JSONInvertPatch(sourceJSON, patchJSON []byte) (json.RawMessage, error) {
+	// Apply the patch
+	targetJSON, err := JSONApplyPatch(sourceJSON, patchJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// The inverse leads from the target back to the source
+	return JSONDiff(targetJSON, sourceJSON)
+}
+
+/*
+ * Three-way merge
+ */
+
+// A conflict between two sets of changes to a JSON document
+type TJSONConflict struct {
+	Path   string          `json:"path"`             // JSON Pointer to the conflicting part of the document
+	Base   json.RawMessage `json:"base,omitempty"`   // The conflicting part in the base document, if present
+	Ours   json.RawMessage `json:"ours,omitempty"`   // The conflicting part in our document, if present
+	Theirs json.RawMessage `json:"theirs,omitempty"` // The conflicting part in their document, if present
+}
+
+// A part of a JSON document, which may be absent
+type tJSONPart struct {
+	present bool // Whether the part is present
+	value   any  // The value of the part
+}
+
+// Decode a JSON document, keeping numbers as they are
+func decodeJSON(documentJSON []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(documentJSON))
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// Encode a part of a JSON document, which is nil when the part is absent
+func (p tJSONPart) encode() json.RawMessage {
+	if !p.present {
+		return nil
+	}
+
+	partJSON, err := json.Marshal(p.value)
+	if err != nil {
+		return nil
+	}
+
+	return partJSON
+}
+
+// Check whether two parts of JSON documents are the same
+func (p tJSONPart) equals(other tJSONPart) bool {
+	return p.present == other.present && reflect.DeepEqual(p.value, other.value)
+}
+
+// Get the object of a part of a JSON document, if it is one
+func (p tJSONPart) object() (map[string]any, bool) {
+	object, isObject := p.value.(map[string]any)
+	return object, p.present && isObject
+}
+
+// Get a member of a JSON object, which may be absent
+func jsonMember(object map[string]any, key string) tJSONPart {
+	value, present := object[key]
+	return tJSONPart{present: present, value: value}
+}
+
+// Extend a JSON Pointer with a key
+func jsonPointerTo(pointer, key string) string {
+	return pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// Merge the changes from a base part to our and their part, located at a JSON Pointer.
+// Conflicting changes are added to the conflicts, keeping our part.
+func mergeJSONParts(pointer string, base, ours, theirs tJSONPart, conflicts *[]TJSONConflict) tJSONPart {
+	switch {
+	case ours.equals(theirs):
+		// The same changes, if any
+		return ours
+
+	case base.equals(ours):
+		// Only their changes
+		return theirs
+
+	case base.equals(theirs):
+		// Only our changes
+		return ours
+	}
+
+	// Changes on both sides, which can be merged per key when both sides are objects
+	oursObject, oursIsObject := ours.object()
+	theirsObject, theirsIsObject := theirs.object()
+	if !oursIsObject || !theirsIsObject {
+		*conflicts = append(*conflicts, TJSONConflict{
+			Path:   pointer,
+			Base:   base.encode(),
+			Ours:   ours.encode(),
+			Theirs: theirs.encode(),
+		})
+
+		return ours
+	}
+
+	// When the base is not an object, all members are considered to be new
+	baseObject, _ := base.object()
+
+	// Collect the keys, in a fixed order
+	keySet := map[string]bool{}
+	for _, object := range []map[string]any{baseObject, oursObject, theirsObject} {
+		for key := range object {
+			keySet[key] = true
+		}
+	}
+
+	keys := []string{}
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Merge the members
+	merged := map[string]any{}
+	for _, key := range keys {
+		member := mergeJSONParts(jsonPointerTo(pointer, key), jsonMember(baseObject, key), jsonMember(oursObject, key), jsonMember(theirsObject, key), conflicts)
+		if member.present {
+			merged[key] = member.value
+		}
+	}
+
+	return tJSONPart{present: true, value: merged}
+}
+
+// Merge the changes from a base JSON document to our and their JSON document.
+// Changes to different parts of the document are combined. Changes to the same part that differ are conflicts,
+// for which our changes are kept in the merged document.
+func JSONMerge3(baseJSON, oursJSON, theirsJSON []byte) (json.RawMessage, []TJSONConflict, error) {
+	// Decode the documents
+	parts := []tJSONPart{}
+	for _, documentJSON := range [][]byte{baseJSON, oursJSON, theirsJSON} {
+		document, err := decodeJSON(documentJSON)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding JSON to merge: %w", err)
+		}
+		parts = append(parts, tJSONPart{present: true, value: document})
+	}
+
+	// Merge the documents
+	conflicts := []TJSONConflict{}
+	merged := mergeJSONParts("", parts[0], parts[1], parts[2], &conflicts)
+
+	mergedJSON, err := json.Marshal(merged.value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding merged JSON: %w", err)
+	}
+
+	return mergedJSON, conflicts, nil
+}
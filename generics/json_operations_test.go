@@ -0,0 +1,307 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Generic
+ * Component: JSON Operations (tests)
+ *
+ * These tests cover the three-way merge of JSONs, as well as the composition and inversion of patches.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package generics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Check whether two JSON documents are equivalent
+func sameJSON(t *testing.T, json1, json2 []byte) bool {
+	t.Helper()
+
+	var value1, value2 any
+	if err := json.Unmarshal(json1, &value1); err != nil {
+		t.Fatalf("unJSONing %s: %s", json1, err)
+	}
+	if err := json.Unmarshal(json2, &value2); err != nil {
+		t.Fatalf("unJSONing %s: %s", json2, err)
+	}
+
+	return reflect.DeepEqual(value1, value2)
+}
+
+func TestJSONMerge3(t *testing.T) {
+	for _, test := range []struct {
+		name                string
+		base, ours, theirs  string
+		merged              string
+		conflictingPointers []string
+	}{
+		{
+			name: "no changes",
+			base: `{"a":1}`, ours: `{"a":1}`, theirs: `{"a":1}`,
+			merged: `{"a":1}`,
+		},
+		{
+			name: "only ours",
+			base: `{"a":1}`, ours: `{"a":2}`, theirs: `{"a":1}`,
+			merged: `{"a":2}`,
+		},
+		{
+			name: "only theirs",
+			base: `{"a":1}`, ours: `{"a":1}`, theirs: `{"a":2}`,
+			merged: `{"a":2}`,
+		},
+		{
+			name: "same changes",
+			base: `{"a":1}`, ours: `{"a":2}`, theirs: `{"a":2}`,
+			merged: `{"a":2}`,
+		},
+		{
+			name: "different keys",
+			base: `{"a":1,"b":1}`, ours: `{"a":2,"b":1}`, theirs: `{"a":1,"b":2}`,
+			merged: `{"a":2,"b":2}`,
+		},
+		{
+			name: "added and removed keys",
+			base: `{"a":1,"b":1}`, ours: `{"a":1,"b":1,"c":1}`, theirs: `{"a":1}`,
+			merged: `{"a":1,"c":1}`,
+		},
+		{
+			name: "nested objects",
+			base: `{"classes":{"x":{"name":"X"},"y":{"name":"Y"}}}`, ours: `{"classes":{"x":{"name":"X1"},"y":{"name":"Y"}}}`, theirs: `{"classes":{"x":{"name":"X"},"y":{"name":"Y1"}}}`,
+			merged: `{"classes":{"x":{"name":"X1"},"y":{"name":"Y1"}}}`,
+		},
+		{
+			name: "conflicting values",
+			base: `{"a":1,"b":1}`, ours: `{"a":2,"b":1}`, theirs: `{"a":3,"b":2}`,
+			merged: `{"a":2,"b":2}`, conflictingPointers: []string{"/a"},
+		},
+		{
+			name: "changed and removed",
+			base: `{"a":{"b":1}}`, ours: `{"a":{"b":2}}`, theirs: `{}`,
+			merged: `{"a":{"b":2}}`, conflictingPointers: []string{"/a"},
+		},
+		{
+			name: "arrays as a whole",
+			base: `{"a":[1,2]}`, ours: `{"a":[1,2,3]}`, theirs: `{"a":[0,1,2]}`,
+			merged: `{"a":[1,2,3]}`, conflictingPointers: []string{"/a"},
+		},
+		{
+			name: "escaped keys",
+			base: `{"a/b":1,"c~d":1}`, ours: `{"a/b":2,"c~d":2}`, theirs: `{"a/b":3,"c~d":3}`,
+			merged: `{"a/b":2,"c~d":2}`, conflictingPointers: []string{"/a~1b", "/c~0d"},
+		},
+		{
+			name: "whole documents",
+			base: `1`, ours: `2`, theirs: `3`,
+			merged: `2`, conflictingPointers: []string{""},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			merged, conflicts, err := JSONMerge3([]byte(test.base), []byte(test.ours), []byte(test.theirs))
+			if err != nil {
+				t.Fatalf("merging: %s", err)
+			}
+			if !sameJSON(t, merged, []byte(test.merged)) {
+				t.Errorf("expected %s, got %s", test.merged, merged)
+			}
+
+			conflictingPointers := []string{}
+			for _, conflict := range conflicts {
+				conflictingPointers = append(conflictingPointers, conflict.Path)
+			}
+			if !reflect.DeepEqual(conflictingPointers, append([]string{}, test.conflictingPointers...)) {
+				t.Errorf("expected conflicts at %v, got %v", test.conflictingPointers, conflictingPointers)
+			}
+		})
+	}
+}
+
+func TestJSONMerge3ReportsConflictingParts(t *testing.T) {
+	_, conflicts, err := JSONMerge3([]byte(`{"a":1}`), []byte(`{"a":2}`), []byte(`{}`))
+	if err != nil {
+		t.Fatalf("merging: %s", err)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("expected one conflict, got %v", conflicts)
+	}
+
+	conflict := conflicts[0]
+	if string(conflict.Base) != `1` || string(conflict.Ours) != `2` || conflict.Theirs != nil {
+		t.Errorf("expected base 1, ours 2, and theirs absent, got %s, %s, and %s", conflict.Base, conflict.Ours, conflict.Theirs)
+	}
+}
+
+func TestJSONMerge3RejectsInvalidJSON(t *testing.T) {
+	if _, _, err := JSONMerge3([]byte(`{}`), []byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("merged invalid JSON")
+	}
+}
+
+func TestJSONComposePatches(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		source        string
+		first, second string
+		operations    int
+	}{
+		{
+			name:   "replacing twice",
+			source: `{"a":1}`, first: `[{"op":"replace","path":"/a","value":2}]`, second: `[{"op":"replace","path":"/a","value":3}]`,
+			operations: 1,
+		},
+		{
+			name:   "adding and replacing",
+			source: `{}`, first: `[{"op":"add","path":"/a","value":2}]`, second: `[{"op":"replace","path":"/a","value":3}]`,
+			operations: 1,
+		},
+		{
+			name:   "replacing and removing",
+			source: `{"a":1}`, first: `[{"op":"replace","path":"/a","value":2}]`, second: `[{"op":"remove","path":"/a"}]`,
+			operations: 1,
+		},
+		{
+			name:   "removing and adding",
+			source: `{"a":1}`, first: `[{"op":"remove","path":"/a"}]`, second: `[{"op":"add","path":"/a","value":3}]`,
+			operations: 1,
+		},
+		{
+			name:   "different parts",
+			source: `{"a":1,"b":1}`, first: `[{"op":"replace","path":"/a","value":2}]`, second: `[{"op":"replace","path":"/b","value":2}]`,
+			operations: 2,
+		},
+		{
+			name:   "nested parts",
+			source: `{"a":{"b":1}}`, first: `[{"op":"replace","path":"/a","value":{"b":2}}]`, second: `[{"op":"replace","path":"/a/b","value":3}]`,
+			operations: 2,
+		},
+		{
+			name:   "array elements",
+			source: `{"a":[0,1,2]}`, first: `[{"op":"replace","path":"/a/1","value":10}]`, second: `[{"op":"replace","path":"/a/1","value":11}]`,
+			operations: 1,
+		},
+		{
+			name:   "array element removed in between",
+			source: `{"a":[0,1,2]}`, first: `[{"op":"replace","path":"/a/1","value":10},{"op":"remove","path":"/a/0"}]`, second: `[{"op":"replace","path":"/a/1","value":12}]`,
+			operations: 3,
+		},
+		{
+			name:   "array element added in between",
+			source: `{"a":[0,1,2]}`, first: `[{"op":"replace","path":"/a/1","value":10},{"op":"add","path":"/a/0","value":-1}]`, second: `[{"op":"replace","path":"/a/1","value":11}]`,
+			operations: 3,
+		},
+		{
+			name:   "array element of nested part removed in between",
+			source: `{"a":[{"b":0},{"b":1},{"b":2}]}`, first: `[{"op":"replace","path":"/a/1/b","value":10},{"op":"remove","path":"/a/0"}]`, second: `[{"op":"replace","path":"/a/1/b","value":12}]`,
+			operations: 3,
+		},
+		{
+			name:   "array element removed before one replaced in between",
+			source: `{"a":[0,1,2,3]}`, first: `[{"op":"replace","path":"/a/1","value":10},{"op":"replace","path":"/a/2","value":20}]`, second: `[{"op":"remove","path":"/a/1"}]`,
+			operations: 3,
+		},
+		{
+			name:   "other array changed in between",
+			source: `{"a":[0,1],"b":[0,1]}`, first: `[{"op":"replace","path":"/a/1","value":10},{"op":"remove","path":"/b/0"}]`, second: `[{"op":"replace","path":"/a/1","value":11}]`,
+			operations: 2,
+		},
+		{
+			name:   "appending twice",
+			source: `{"a":[0]}`, first: `[{"op":"add","path":"/a/-","value":1}]`, second: `[{"op":"add","path":"/a/-","value":2}]`,
+			operations: 2,
+		},
+		{
+			name:   "empty patches",
+			source: `{"a":1}`, first: `[]`, second: `null`,
+			operations: 0,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			composed, err := JSONComposePatches([]byte(test.first), []byte(test.second))
+			if err != nil {
+				t.Fatalf("composing: %s", err)
+			}
+
+			operations := []tJSONPatchOperation{}
+			if err := json.Unmarshal(composed, &operations); err != nil {
+				t.Fatalf("unJSONing %s: %s", composed, err)
+			}
+			if len(operations) != test.operations {
+				t.Errorf("expected %d operations, got %s", test.operations, composed)
+			}
+
+			// Applying the composed patch has the same effect as applying both patches in turn
+			intermediate, err := JSONApplyPatch([]byte(test.source), []byte(test.first))
+			if err != nil {
+				t.Fatalf("applying the first patch: %s", err)
+			}
+			expected := intermediate
+			if len(test.second) > 0 && test.second != "null" {
+				if expected, err = JSONApplyPatch(intermediate, []byte(test.second)); err != nil {
+					t.Fatalf("applying the second patch: %s", err)
+				}
+			}
+
+			got, err := JSONApplyPatch([]byte(test.source), composed)
+			if err != nil {
+				t.Fatalf("applying the composed patch %s: %s", composed, err)
+			}
+			if !sameJSON(t, got, expected) {
+				t.Errorf("composed patch %s results in %s, rather than %s", composed, got, expected)
+			}
+		})
+	}
+}
+
+func TestJSONComposePatchesRejectsInvalidPatches(t *testing.T) {
+	if _, err := JSONComposePatches([]byte(`[]`), []byte(`{`)); err == nil {
+		t.Error("composed an invalid patch")
+	}
+}
+
+func TestJSONInvertPatch(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		source string
+		patch  string
+	}{
+		{name: "replacing", source: `{"a":1}`, patch: `[{"op":"replace","path":"/a","value":2}]`},
+		{name: "adding", source: `{}`, patch: `[{"op":"add","path":"/a","value":{"b":1}}]`},
+		{name: "removing", source: `{"a":{"b":1}}`, patch: `[{"op":"remove","path":"/a"}]`},
+		{name: "array elements", source: `{"a":[0,1,2]}`, patch: `[{"op":"replace","path":"/a/1","value":10},{"op":"remove","path":"/a/0"},{"op":"add","path":"/a/-","value":3}]`},
+		{name: "moving", source: `{"a":1,"b":{}}`, patch: `[{"op":"move","from":"/a","path":"/b/a"}]`},
+		{name: "nothing", source: `{"a":1}`, patch: `[]`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			inverse, err := JSONInvertPatch([]byte(test.source), []byte(test.patch))
+			if err != nil {
+				t.Fatalf("inverting: %s", err)
+			}
+
+			// Applying the patch and its inverse results in the source again
+			target, err := JSONApplyPatch([]byte(test.source), []byte(test.patch))
+			if err != nil {
+				t.Fatalf("applying the patch: %s", err)
+			}
+			got, err := JSONApplyPatch(target, inverse)
+			if err != nil {
+				t.Fatalf("applying the inverse %s: %s", inverse, err)
+			}
+			if !sameJSON(t, got, []byte(test.source)) {
+				t.Errorf("inverse %s results in %s, rather than %s", inverse, got, test.source)
+			}
+		})
+	}
+}
+
+func TestJSONInvertPatchRejectsInapplicablePatches(t *testing.T) {
+	if _, err := JSONInvertPatch([]byte(`{}`), []byte(`[{"op":"remove","path":"/a"}]`)); err == nil {
+		t.Error("inverted a patch that does not apply")
+	}
+}
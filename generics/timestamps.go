@@ -6,10 +6,12 @@
  *
  * This component computes unique (within the present run-time environment) timestamps.
  * The uniqueness is based on the current time up to seconds, and is combined with a counter
+ * Timestamps may be requested from different goroutines.
+ * As the counter may grow beyond two digits, timestamps should be compared using CompareTimestamps.
  *
  * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
  *
- * Version of: 27.11.2025
+ * Version of: 16.10.2026
  *
  */
 
@@ -17,15 +19,22 @@ package generics
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 var (
+	timestampMutex    sync.Mutex
 	timestampCounter  int
 	lastTimeTimestamp string
 )
 
 func GetTimestamp() string {
+	timestampMutex.Lock()
+	defer timestampMutex.Unlock()
+
 	CurrenTime := time.Now()
 
 	timeTimestamp := fmt.Sprintf(
@@ -47,6 +56,42 @@ func GetTimestamp() string {
 	return fmt.Sprintf("%s-%02d", lastTimeTimestamp, timestampCounter)
 }
 
+// Compare two timestamps, resulting in -1 when the first is earlier, 0 when they are the same, and 1 when the
+// first is later. The elements of the timestamps are compared numerically, where possible.
+func CompareTimestamps(timestamp1, timestamp2 string) int {
+	elements1 := strings.Split(timestamp1, "-")
+	elements2 := strings.Split(timestamp2, "-")
+
+	for i := 0; i < len(elements1) && i < len(elements2); i++ {
+		number1, err1 := strconv.Atoi(elements1[i])
+		number2, err2 := strconv.Atoi(elements2[i])
+
+		switch {
+		case err1 != nil || err2 != nil:
+			// Not numerical, so compare as strings
+			if comparison := strings.Compare(elements1[i], elements2[i]); comparison != 0 {
+				return comparison
+			}
+
+		case number1 < number2:
+			return -1
+
+		case number1 > number2:
+			return 1
+		}
+	}
+
+	// All shared elements are the same, so the shorter timestamp is the earlier one
+	switch {
+	case len(elements1) < len(elements2):
+		return -1
+	case len(elements1) > len(elements2):
+		return 1
+	default:
+		return 0
+	}
+}
+
 func init() {
 	timestampCounter = 0
 	lastTimeTimestamp = ""
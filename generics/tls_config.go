@@ -0,0 +1,83 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Generic
+ * Component: TLS Config
+ *
+ * This component creates TLS configurations from the config data, so that connections to brokers and servers can
+ * be secured in a uniform way.
+ * The following keys are read from a given section of the config file:
+ * - tls:                  whether to use TLS at all;
+ * - ca_file:              a PEM file with the certificate authorities to trust, next to those of the system;
+ * - cert_file / key_file: a PEM encoded client certificate and its key, when client authentication is required;
+ * - server_name:          the name of the server to verify the certificate against, when it differs from the host;
+ * - insecure_skip_verify: to skip the verification of the server certificate (for testing purposes only).
+ *
+ * Author: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package generics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Check whether TLS is configured in a given section of the config data
+func (c *TConfigData) TLSEnabled(section string) bool {
+	return c.GetValue(section, "tls").Bool()
+}
+
+// Create a TLS configuration from a given section of the config data.
+// The result is nil, when TLS is not enabled in the given section.
+func (c *TConfigData) TLSConfig(section string) (*tls.Config, error) {
+	// Without TLS, there is nothing to configure
+	if !c.TLSEnabled(section) {
+		return nil, nil
+	}
+
+	// Start with the defaults
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.GetValue(section, "server_name").String(),
+		InsecureSkipVerify: c.GetValue(section, "insecure_skip_verify").Bool(),
+	}
+
+	// Add the certificate authorities from the CA bundle, if provided
+	if caFile := c.GetValue(section, "ca_file").String(); caFile != "" {
+		caBundle, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle %s: %w", caFile, err)
+		}
+
+		// Start from the system's certificate authorities, when available
+		certPool, err := x509.SystemCertPool()
+		if err != nil {
+			certPool = x509.NewCertPool()
+		}
+
+		if !certPool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	// Add the client certificate, if provided
+	certFile := c.GetValue(section, "cert_file").String()
+	keyFile := c.GetValue(section, "key_file").String()
+	if certFile != "" || keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate %s: %w", certFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	// Return the TLS configuration
+	return tlsConfig, nil
+}
@@ -0,0 +1,146 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Generic
+ * Component: TLS Config (tests)
+ *
+ * These tests create TLS configurations from the config data, using a self-signed certificate created on the fly.
+ *
+ * Author: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package generics
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Write a self-signed certificate and its key to PEM files in the given directory, returning their paths
+func writeTestCertificate(t *testing.T, directory string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(directory, "cert.pem")
+	keyFile = filepath.Join(directory, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	directory := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, directory)
+	emptyFile := filepath.Join(directory, "empty.pem")
+	if err := os.WriteFile(emptyFile, []byte{}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		section string
+		check   func(*tls.Config) bool
+		fails   bool
+	}{
+		{
+			name:    "not enabled",
+			section: "tls = false\nca_file = " + certFile,
+			check:   func(c *tls.Config) bool { return c == nil },
+		},
+		{
+			name:    "defaults",
+			section: "tls = true",
+			check: func(c *tls.Config) bool {
+				return c != nil && c.MinVersion == tls.VersionTLS12 && c.RootCAs == nil && len(c.Certificates) == 0 && !c.InsecureSkipVerify
+			},
+		},
+		{
+			name:    "server name and insecure",
+			section: "tls = true\nserver_name = broker.example.org\ninsecure_skip_verify = true",
+			check:   func(c *tls.Config) bool { return c.ServerName == "broker.example.org" && c.InsecureSkipVerify },
+		},
+		{
+			name:    "CA bundle",
+			section: "tls = true\nca_file = " + certFile,
+			check:   func(c *tls.Config) bool { return c.RootCAs != nil },
+		},
+		{
+			name:    "missing CA bundle",
+			section: "tls = true\nca_file = " + filepath.Join(directory, "missing.pem"),
+			fails:   true,
+		},
+		{
+			name:    "CA bundle without certificates",
+			section: "tls = true\nca_file = " + emptyFile,
+			fails:   true,
+		},
+		{
+			name:    "client certificate",
+			section: "tls = true\ncert_file = " + certFile + "\nkey_file = " + keyFile,
+			check:   func(c *tls.Config) bool { return len(c.Certificates) == 1 },
+		},
+		{
+			name:    "client certificate without key",
+			section: "tls = true\ncert_file = " + certFile,
+			fails:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configData, err := TryLoadConfigFromData([]byte("[mqtt]\n" + test.section))
+			if err != nil {
+				t.Fatalf("loading the config data: %s", err)
+			}
+
+			tlsConfig, err := configData.TLSConfig("mqtt")
+			switch {
+			case test.fails && err == nil:
+				t.Error("expected an error")
+			case !test.fails && err != nil:
+				t.Errorf("unexpected error: %s", err)
+			case !test.fails && !test.check(tlsConfig):
+				t.Errorf("unexpected TLS configuration for %s", strings.ReplaceAll(test.section, "\n", ", "))
+			}
+		})
+	}
+}
@@ -5,13 +5,16 @@ go 1.24.0
 require (
 	github.com/eclipse/paho.mqtt.golang v1.5.1
 	github.com/evanphx/json-patch v0.5.2
+	github.com/pkg/sftp v1.13.9
 	github.com/secsy/goftp v0.0.0-20200609142545-aa2de14babf4
 	github.com/wI2L/jsondiff v0.7.0
+	golang.org/x/crypto v0.45.0
 	gopkg.in/ini.v1 v1.67.0
 )
 
 require (
 	github.com/gorilla/websocket v1.5.3 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
 	github.com/tidwall/gjson v1.18.0 // indirect
@@ -20,4 +23,5 @@ require (
 	github.com/tidwall/sjson v1.2.5 // indirect
 	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
 )
@@ -194,6 +194,22 @@ func (m *TCDMModel) PostConsidering() {
 	m.ModellingBusArtefactPoster.PostJSONArtefactConsidering(json.Marshal(m))
 }
 
+func (m *TCDMModel) Undo() bool {
+	if !m.ModellingBusArtefactPoster.Undo() {
+		return false
+	}
+
+	return m.GetConsideredFromBus(m.ModellingBusArtefactPoster)
+}
+
+func (m *TCDMModel) Redo() bool {
+	if !m.ModellingBusArtefactPoster.Redo() {
+		return false
+	}
+
+	return m.GetConsideredFromBus(m.ModellingBusArtefactPoster)
+}
+
 /*
  *
  * Reading models from the artefactBus
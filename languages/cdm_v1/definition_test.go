@@ -0,0 +1,73 @@
+/*
+ *
+ * Module:    BIG Modelling Bus
+ * Package:   Languages/Conceptual Domain Modelling, Version 1
+ *
+ * These tests post CDM models, and undo and redo changes to them.
+ *
+ * Creator: Henderik A. Proper (e.proper@acm.org), TU Wien, Austria
+ *
+ * Version of: 16.10.2026
+ *
+ */
+
+package cdm_v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/erikproper/big-modelling-bus.go.v1/connect"
+	"github.com/erikproper/big-modelling-bus.go.v1/generics"
+)
+
+// Create a CDM poster for tests, using the in-memory bus and repository named after the test
+func createTestCDMPoster(t *testing.T) TCDMModel {
+	configData, err := generics.TryLoadConfigFromData([]byte(fmt.Sprintf("environment = test\nagent = poster\nwork_folder = %s\n\n"+
+		"[events]\nkind = memory\n\n[repository]\nkind = memory\n\n[memory]\nname = %s\n", t.TempDir(), t.Name())))
+	if err != nil {
+		t.Fatalf("loading the config data: %s", err)
+	}
+
+	reporter := generics.CreateReporter(generics.ProgressLevelBasic, func(string) {}, func(message string) { t.Log(message) })
+	connector, err := connect.CreateModellingBusConnectorContext(context.Background(), configData, reporter, false)
+	if err != nil {
+		t.Fatalf("creating the connector: %s", err)
+	}
+	t.Cleanup(func() { connector.Close(context.Background()) })
+
+	return CreateCDMPoster(connector, "model")
+}
+
+func TestCDMUndoRedo(t *testing.T) {
+	model := createTestCDMPoster(t)
+
+	// Without changes, there is nothing to undo or redo, and the model is left as it is
+	model.SetModelName("Model")
+	model.PostState()
+	model.AddConcreteIndividualType("Person")
+
+	if model.Undo() || model.Redo() {
+		t.Error("undone or redone without changes")
+	}
+	if len(model.ConcreteIndividualTypes) != 1 {
+		t.Error("the model was reset")
+	}
+
+	// Undoing and redoing a posted change
+	model.PostUpdate()
+	if !model.Undo() {
+		t.Fatal("nothing undone")
+	}
+	if len(model.ConcreteIndividualTypes) != 0 {
+		t.Errorf("expected no concrete individual types after undoing, got %v", model.ConcreteIndividualTypes)
+	}
+
+	if !model.Redo() {
+		t.Fatal("nothing redone")
+	}
+	if len(model.ConcreteIndividualTypes) != 1 {
+		t.Errorf("expected one concrete individual type after redoing, got %v", model.ConcreteIndividualTypes)
+	}
+}